@@ -38,6 +38,9 @@ func before(c *cli.Context) error {
 	return nil
 }
 
+// gardenVersion : the version of garden, also recorded in the garden state
+const gardenVersion = "0.9.5"
+
 func printVersion(c *cli.Context) {
 	fmt.Fprintf(c.App.Writer, "%v\n", c.App.Version)
 }
@@ -49,7 +52,7 @@ func Run() {
 	app := cli.NewApp()
 	app.Name = path.Base(os.Args[0])
 	app.Usage = "garden"
-	app.Version = "0.9.5"
+	app.Version = gardenVersion
 
 	app.Author = ""
 	app.Email = ""
@@ -25,20 +25,201 @@ const (
 	ZoneKey = "zone"
 	// PlantKey ...
 	PlantKey = "plant"
+
+	// --- Command flags
+
+	// UpdateKey ...
+	UpdateKey = "update"
+	// VarKey ...
+	VarKey = "var"
+	// IgnoreKey ...
+	IgnoreKey = "ignore"
+	// DryRunKey ...
+	DryRunKey = "dry-run"
+	// StatKey ...
+	StatKey = "stat"
+	// ExitCodeKey ...
+	ExitCodeKey = "exit-code"
+	// OnConflictKey ...
+	OnConflictKey = "on-conflict"
+	// PruneKey ...
+	PruneKey = "prune"
+	// YesKey ...
+	YesKey = "yes"
+	// ForceKey ...
+	ForceKey = "force"
+	// JobsKey ...
+	JobsKey = "jobs"
+	// KeepGoingKey ...
+	KeepGoingKey = "keep-going"
+	// SnapshotKey ...
+	SnapshotKey = "snapshot"
+	// AtKey ...
+	AtKey = "at"
 )
 
 var (
 	commands = []cli.Command{
+		{
+			Name:      "init",
+			Usage:     "Create a new garden directory (default: ./.garden)",
+			ArgsUsage: "[dir]",
+			Action:    initGarden,
+		},
 		{
 			Name:   "grow",
 			Usage:  "Grow your plants!",
 			Action: grow,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  DryRunKey,
+					Usage: "Render the plants and report what would change, without changing the plant dirs",
+				},
+				cli.StringFlag{
+					Name:  OnConflictKey,
+					Value: "fail",
+					Usage: "What to do with the locally edited files the seed would change, if no conflict_policies pattern matches them (options: fail, skip, backup, force, merge)",
+				},
+				cli.BoolFlag{
+					Name:  PruneKey,
+					Usage: "Remove the files a previous grow wrote, but the seed no longer produces",
+				},
+				cli.BoolFlag{
+					Name:  YesKey,
+					Usage: "Don't ask for confirmation before pruning",
+				},
+				cli.BoolFlag{
+					Name:  ForceKey,
+					Usage: "Grow the plants which are up to date too",
+				},
+				cli.IntFlag{
+					Name:  JobsKey,
+					Value: 1,
+					Usage: "The number of plants to grow at the same time",
+				},
+				cli.BoolFlag{
+					Name:  KeepGoingKey,
+					Usage: "Grow every plant, even if an other plant failed",
+				},
+				cli.BoolFlag{
+					Name:  SnapshotKey,
+					Usage: "Take a snapshot of every plant's garden managed files before it's grown",
+				},
+			},
+		},
+		{
+			Name:   "diff",
+			Usage:  "Show how the plants would change, compared to the rendered seeds",
+			Action: diff,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  StatKey,
+					Usage: "Print only the changed files, with the number of changed lines",
+				},
+				cli.BoolFlag{
+					Name:  ExitCodeKey,
+					Usage: "Exit with status code 1 if any of the plants differ (errors exit with status code 2)",
+				},
+				cli.StringFlag{
+					Name:  OnConflictKey,
+					Value: "fail",
+					Usage: "The conflict policy of the grow to compare with, the locally edited files a grow would skip are not shown (options: fail, skip, backup, force, merge)",
+				},
+			},
+		},
+		{
+			Name:   "uproot",
+			Usage:  "Remove the files garden created for the plants (the locally edited files are kept)",
+			Action: uproot,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  YesKey,
+					Usage: "Don't ask for confirmation",
+				},
+			},
+		},
+		{
+			Name:   "snapshots",
+			Usage:  "List the snapshots of the plants",
+			Action: snapshots,
+		},
+		{
+			Name:   "restore",
+			Usage:  "Restore a plant's files from a snapshot",
+			Action: restore,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  PlantKey,
+					Usage: "The ID of the plant to restore",
+				},
+				cli.StringFlag{
+					Name:  AtKey,
+					Usage: "The snapshot's ID, or a time (RFC3339): the latest snapshot taken at or before it (default: the latest snapshot)",
+				},
+				cli.BoolFlag{
+					Name:  YesKey,
+					Usage: "Don't ask for confirmation",
+				},
+			},
 		},
 		{
 			Name:   "reap",
 			Usage:  "Use your plants!",
 			Action: reap,
 		},
+		{
+			Name:      "lock",
+			Usage:     "Lock the revisions of remote seeds in garden.lock",
+			ArgsUsage: "[seed]",
+			Action:    lock,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  UpdateKey,
+					Usage: "Resolve the seeds (or only the specified seed) again, instead of using the locked revisions",
+				},
+			},
+		},
+		{
+			Name:  "seed",
+			Usage: "Seed development tools",
+			Subcommands: []cli.Command{
+				{
+					Name:      "test",
+					Usage:     "Render the seeds' test cases and compare them with the golden directories",
+					ArgsUsage: "[seed...]",
+					Action:    seedTest,
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  UpdateKey,
+							Usage: "Rewrite the golden directories with the rendered output",
+						},
+					},
+				},
+				{
+					Name:      "capture",
+					Usage:     "Create a new seed from an existing directory",
+					ArgsUsage: "DIR SEED-NAME",
+					Action:    seedCapture,
+					Flags: []cli.Flag{
+						cli.StringSliceFlag{
+							Name:  VarKey,
+							Value: &cli.StringSlice{},
+							Usage: "Literal=VarName : replace the literal with the var in the captured files (can be specified multiple times)",
+						},
+						cli.StringSliceFlag{
+							Name:  IgnoreKey,
+							Value: &cli.StringSlice{},
+							Usage: "Glob pattern of the paths which should not be captured (can be specified multiple times)",
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:   "lint",
+			Usage:  "Report the vars of the plants which are not used by their seeds",
+			Action: lint,
+		},
 		{
 			Name:   "view",
 			Usage:  "View your plants!",
@@ -1,24 +1,99 @@
 package cli
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
+	"os/user"
 	"path"
+	"runtime"
+	"strings"
+	"time"
 
 	"text/template"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/bitrise-io/go-utils/pathutil"
 )
 
+const (
+	// partialsDirName : name of the template partials directory,
+	//  both in the garden dir and in a seed's meta dir
+	partialsDirName = "partials"
+	partialFileExt  = ".tmpl"
+	// cacheDirName : name of the cache directory, inside the garden dir
+	cacheDirName = ".cache"
+)
+
+// confirmFunc ...
+//  asks the user to confirm an action, returns false if it's declined
+type confirmFunc func(question string) (bool, error)
+
+// askForConfirmation ...
+//  reads the answer from the standard input, only `y` and `yes` confirm
+func askForConfirmation(question string) (bool, error) {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// GardenTemplateHostModel ...
+//  information about the host garden runs on
+type GardenTemplateHostModel struct {
+	Hostname string
+	User     string
+	OS       string
+	Arch     string
+	HomeDir  string
+}
+
 // GardenTemplateInventoryModel ...
 type GardenTemplateInventoryModel struct {
-	Vars      map[string]string
-	TestBool  bool
-	PlantID   string
+	Vars    map[string]string
+	PlantID string
+	// PlantPath : absolute path of the plant
 	PlantPath string
+	// PlantExpandedPath : the plant's path, as defined in the map,
+	//  with $_GARDEN_PLANT_ID expanded
+	PlantExpandedPath string
+	Zones             []string
+	Seed              string
+	// GardenDir : absolute path of the garden directory
+	GardenDir string
+	Host      GardenTemplateHostModel
+	// Timestamp : the time the grow was started,
+	//  the same for every plant of a single grow
+	Timestamp time.Time
+}
+
+// createTemplateHostModel ...
+func createTemplateHostModel() GardenTemplateHostModel {
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Warnf("Failed to get hostname, error: %s", err)
+	}
+
+	userName := os.Getenv("USER")
+	if currentUser, err := user.Current(); err == nil {
+		userName = currentUser.Username
+	}
+
+	return GardenTemplateHostModel{
+		Hostname: hostname,
+		User:     userName,
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		HomeDir:  pathutil.UserHomeDir(),
+	}
 }
 
 func createAvailableTemplateFunctions(inventory GardenTemplateInventoryModel) template.FuncMap {
-	return template.FuncMap{
+	funcs := template.FuncMap{
 		"isOne": func(i int) bool {
 			return i == 1
 		},
@@ -29,13 +104,24 @@ func createAvailableTemplateFunctions(inventory GardenTemplateInventoryModel) te
 			}
 			return val, nil
 		},
+		"optionalVar": func(key string) string {
+			return inventory.Vars[key]
+		},
 		"notEmpty": func(val string) (string, error) {
 			if val == "" {
 				return "", fmt.Errorf("Value was empty")
 			}
 			return val, nil
 		},
+		"hasVar": func(key string) bool {
+			_, isFound := inventory.Vars[key]
+			return isFound
+		},
+	}
+	for name, fn := range standardTemplateFunctions() {
+		funcs[name] = fn
 	}
+	return funcs
 }
 
 func checkSeedDir(gardenDirAbsPth, seedPath string) (string, error) {
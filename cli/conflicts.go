@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// conflictBackupTimeFormat : the timestamp format of the backup files' extension
+const conflictBackupTimeFormat = "20060102150405"
+
+// fileConflictModel ...
+//  a plant's file which was edited locally since garden last wrote it,
+//  and which the seed would change too
+type fileConflictModel struct {
+	// Path : slash separated, relative to the plant's root
+	Path   string
+	Policy string
+	// BackupPath : the path of the backup file (backup policy, or merge
+	//  policy if the file can't be merged)
+	BackupPath string
+	// IsMerged : the local and the rendered versions were merged (merge policy)
+	IsMerged bool
+	// HasMergeConflicts : the merged file includes conflict markers
+	HasMergeConflicts bool
+	// RenderedContent : the rendered version of a merged file,
+	//  the base of the next merge
+	RenderedContent string
+}
+
+// String ...
+func (conflict fileConflictModel) String() string {
+	switch {
+	case conflict.HasMergeConflicts:
+		return conflict.Path + " (merged, with conflict markers)"
+	case conflict.IsMerged:
+		return conflict.Path + " (merged)"
+	case conflict.BackupPath != "":
+		return fmt.Sprintf("%s (backed up to %s, then overwritten)", conflict.Path, conflict.BackupPath)
+	case conflict.Policy == config.ConflictPolicySkip:
+		return conflict.Path + " (skipped, the local version is kept)"
+	case conflict.Policy == config.ConflictPolicyForce:
+		return conflict.Path + " (overwritten)"
+	}
+	return conflict.Path + " (grow failed)"
+}
+
+// isTextContent ...
+func isTextContent(content string) bool {
+	return !strings.Contains(content, "\x00")
+}
+
+// collectFileHashes ...
+//  the SHA256 hashes of the regular files of the dir,
+//  by slash separated relative path
+func collectFileHashes(dirPth string) (map[string]string, error) {
+	fileHashes := map[string]string{}
+	err := filepath.Walk(dirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fileInfo.Mode().IsRegular() {
+			return nil
+		}
+		relPth, err := filepath.Rel(dirPth, pth)
+		if err != nil {
+			return err
+		}
+		hash, err := fileSHA256(pth)
+		if err != nil {
+			return err
+		}
+		fileHashes[filepath.ToSlash(relPth)] = hash
+		return nil
+	})
+	return fileHashes, err
+}
+
+// detectConflicts ...
+//  compares the rendered files with the plant's files: a file is
+//  a conflict if its hash differs from the one recorded when garden
+//  last wrote it (it was edited locally) and from the rendered file's hash.
+//  Files which garden didn't write yet, and the files rendered from the
+//  plant's current file (append, patch, merge) are never conflicts.
+func detectConflicts(renderedDirPth, plantDirPth string, plantState config.PlantStateModel, renderedSeed renderedSeedModel, gardenMap config.GardenMapModel, defaultPolicy string) ([]fileConflictModel, error) {
+	renderedFileHashes, err := collectFileHashes(renderedDirPth)
+	if err != nil {
+		return []fileConflictModel{}, err
+	}
+
+	renderedPaths := []string{}
+	for aPth := range renderedFileHashes {
+		renderedPaths = append(renderedPaths, aPth)
+	}
+	sort.Strings(renderedPaths)
+
+	conflicts := []fileConflictModel{}
+	for _, aPth := range renderedPaths {
+		recordedHash, isRecorded := plantState.Files[aPth]
+		if !isRecorded || renderedSeed.PlantDerivedFiles[aPth] {
+			continue
+		}
+		plantFilePth := filepath.Join(plantDirPth, filepath.FromSlash(aPth))
+		fileInfo, err := os.Lstat(plantFilePth)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return []fileConflictModel{}, err
+		}
+		if !fileInfo.Mode().IsRegular() {
+			continue
+		}
+		currentHash, err := fileSHA256(plantFilePth)
+		if err != nil {
+			return []fileConflictModel{}, err
+		}
+		if currentHash == recordedHash || currentHash == renderedFileHashes[aPth] {
+			continue
+		}
+		conflicts = append(conflicts, fileConflictModel{
+			Path:   aPth,
+			Policy: gardenMap.ConflictPolicyForPath(aPth, defaultPolicy),
+		})
+	}
+	return conflicts, nil
+}
+
+// mergeConflict ...
+//  three-way merges the plant's file with the rendered file, the merged
+//  content is written into the rendered dir. Returns false if the file
+//  can't be merged: there's no merge base or the file is not a text file.
+func mergeConflict(conflict *fileConflictModel, renderedDirPth, plantDirPth, mergeBasesDirPth string) (bool, error) {
+	basePth := filepath.Join(mergeBasesDirPth, filepath.FromSlash(conflict.Path))
+	if isExist, err := pathutil.IsPathExists(basePth); err != nil || !isExist {
+		return false, err
+	}
+	renderedPth := filepath.Join(renderedDirPth, filepath.FromSlash(conflict.Path))
+	contents := []string{}
+	for _, aPth := range []string{basePth, filepath.Join(plantDirPth, filepath.FromSlash(conflict.Path)), renderedPth} {
+		content, err := fileutil.ReadStringFromFile(aPth)
+		if err != nil {
+			return false, err
+		}
+		if !isTextContent(content) {
+			return false, nil
+		}
+		contents = append(contents, content)
+	}
+
+	mergedContent, hasMergeConflicts := mergeThreeWay(contents[0], contents[1], contents[2])
+	perms, err := fileutil.GetFilePermissions(renderedPth)
+	if err != nil {
+		return false, err
+	}
+	if err := fileutil.WriteStringToFileWithPermission(renderedPth, mergedContent, perms); err != nil {
+		return false, err
+	}
+	conflict.IsMerged = true
+	conflict.HasMergeConflicts = hasMergeConflicts
+	conflict.RenderedContent = contents[2]
+	return true, nil
+}
+
+// resolveConflicts ...
+//  applies the conflicts' policies, before the rendered dir is copied
+//  into the plant: fails if any of the conflicts has the fail policy,
+//  removes the skipped files from the rendered dir, writes the merged
+//  files into the rendered dir, and backs up the plant's files which
+//  will be overwritten (and the ones which can't be merged), the backups
+//  are saved into the transaction (if it's not nil) before they're created.
+//  In a dry run the plant is not changed, and the fail policy doesn't fail.
+func resolveConflicts(conflicts []fileConflictModel, renderedDirPth, plantDirPth, mergeBasesDirPth string, timestamp time.Time, isDryRun bool, transaction *plantTransactionModel, logger *log.Logger) error {
+	failedPaths := []string{}
+	for _, aConflict := range conflicts {
+		if aConflict.Policy == config.ConflictPolicyFail {
+			failedPaths = append(failedPaths, aConflict.Path)
+		}
+	}
+	if len(failedPaths) > 0 && !isDryRun {
+		for _, aConflict := range conflicts {
+			logger.Warnf(" -> conflict: %s (policy: %s)", aConflict.Path, aConflict.Policy)
+		}
+		return fmt.Errorf("Files edited locally since the last grow would be overwritten: %s - keep the local changes with `--%s skip` or `--%s merge`, or overwrite them with `--%s backup` or `--%s force`",
+			strings.Join(failedPaths, ", "), OnConflictKey, OnConflictKey, OnConflictKey, OnConflictKey)
+	}
+
+	for idx := range conflicts {
+		conflict := &conflicts[idx]
+		switch conflict.Policy {
+		case config.ConflictPolicySkip:
+			if err := os.Remove(filepath.Join(renderedDirPth, filepath.FromSlash(conflict.Path))); err != nil {
+				return err
+			}
+		case config.ConflictPolicyMerge:
+			isMerged, err := mergeConflict(conflict, renderedDirPth, plantDirPth, mergeBasesDirPth)
+			if err != nil {
+				return fmt.Errorf("Failed to merge file (%s), error: %s", conflict.Path, err)
+			}
+			if !isMerged {
+				logger.Warnf(" -> %s can't be merged (no merge base, or not a text file), it's backed up instead", conflict.Path)
+				conflict.BackupPath = backupPathForConflict(conflict.Path, timestamp)
+			}
+		case config.ConflictPolicyBackup:
+			conflict.BackupPath = backupPathForConflict(conflict.Path, timestamp)
+		}
+		logger.Warnf(" -> conflict: %s", conflict)
+
+		if conflict.BackupPath == "" || isDryRun {
+			continue
+		}
+		if err := backupPlantFile(plantDirPth, conflict.Path, conflict.BackupPath, transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func backupPathForConflict(pth string, timestamp time.Time) string {
+	return pth + "." + timestamp.Format(conflictBackupTimeFormat) + ".garden-backup"
+}
+
+// backupPlantFile ...
+//  copies the plant's file (slash separated, plant root relative paths)
+//  to the backup path, which is saved into the transaction (if it's not nil) first
+func backupPlantFile(plantDirPth, relPth, backupRelPth string, transaction *plantTransactionModel) error {
+	plantFilePth := filepath.Join(plantDirPth, filepath.FromSlash(relPth))
+	fileInfo, err := os.Lstat(plantFilePth)
+	if err != nil {
+		return err
+	}
+	backupPth := filepath.Join(plantDirPth, filepath.FromSlash(backupRelPth))
+	if err := transaction.save(backupPth); err != nil {
+		return err
+	}
+	if err := copyFileContent(plantFilePth, backupPth, fileInfo); err != nil {
+		return fmt.Errorf("Failed to back up file (%s), error: %s", relPth, err)
+	}
+	return nil
+}
+
+// saveMergeBases ...
+//  saves the rendered version of the text files, as the base of the
+//  next three-way merges; the skipped files (which are not in the
+//  rendered dir) keep their previous base. The previous bases are
+//  saved into the transaction (if it's not nil) first.
+func saveMergeBases(mergeBasesDirPth, renderedDirPth string, conflicts []fileConflictModel, transaction *plantTransactionModel) error {
+	renderedContentByPath := map[string]string{}
+	for _, aConflict := range conflicts {
+		if aConflict.IsMerged {
+			renderedContentByPath[aConflict.Path] = aConflict.RenderedContent
+		}
+	}
+
+	return filepath.Walk(renderedDirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fileInfo.Mode().IsRegular() {
+			return nil
+		}
+		relPth, err := filepath.Rel(renderedDirPth, pth)
+		if err != nil {
+			return err
+		}
+		content, isMerged := renderedContentByPath[filepath.ToSlash(relPth)]
+		if !isMerged {
+			if content, err = fileutil.ReadStringFromFile(pth); err != nil {
+				return err
+			}
+		}
+		if !isTextContent(content) {
+			return nil
+		}
+		basePth := filepath.Join(mergeBasesDirPth, relPth)
+		if err := transaction.save(basePth); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(basePth), 0755); err != nil {
+			return err
+		}
+		return fileutil.WriteStringToFile(basePth, content)
+	})
+}
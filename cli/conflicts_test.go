@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_growPlants_conflicts(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	seedDirPth := filepath.Join(gardenDirPth, "seeds", "app")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "v1\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "config.yml"), "v1\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, ".gitignore.garden-append"), "/build\n", 0644)
+
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app-1": config.PlantModel{Path: plantDirPth, Seed: "app"},
+		},
+	}
+
+	t.Log("The first grow records the written files")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	gardenState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+	readmeHash, err := fileSHA256(filepath.Join(plantDirPth, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, readmeHash, gardenState.PlantState("app-1").Files["README.md"])
+	require.Equal(t, 3, len(gardenState.PlantState("app-1").Files))
+
+	t.Log("The grow is recorded with the seed's identity and content hash, the vars' hash and garden's version")
+	plantState := gardenState.PlantState("app-1")
+	require.True(t, plantState.IsGrown())
+	require.Equal(t, "app", plantState.Seed)
+	require.Equal(t, "", plantState.SeedRevision)
+	seedHash, err := seedContentHash(seedDirPth)
+	require.NoError(t, err)
+	require.Equal(t, seedHash, plantState.SeedContentHash)
+	emptyVarsHash, err := varsHash(map[string]string{})
+	require.NoError(t, err)
+	require.Equal(t, emptyVarsHash, plantState.VarsHash)
+	require.Equal(t, gardenVersion, plantState.GardenVersion)
+
+	t.Log("Local edits which match the render, and files rendered from the plant's file are not conflicts")
+	writeTestFile(t, filepath.Join(plantDirPth, ".gitignore"), "/build\n/local\n", 0644)
+	writeTestFile(t, filepath.Join(plantDirPth, "config.yml"), "v2\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "config.yml"), "v2\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, ".gitignore.garden-append"), "/build\n/dist\n", 0644)
+	renderedDirPth := filepath.Join(tmpDir, "rendered")
+	writeTestFile(t, filepath.Join(renderedDirPth, "README.md"), "v1\n", 0644)
+	writeTestFile(t, filepath.Join(renderedDirPth, "config.yml"), "v2\n", 0644)
+	writeTestFile(t, filepath.Join(renderedDirPth, ".gitignore"), "/build\n/local\n/dist\n", 0644)
+	conflicts, err := detectConflicts(renderedDirPth, plantDirPth, plantState,
+		renderedSeedModel{PlantDerivedFiles: map[string]bool{".gitignore": true}}, gardenMap, config.ConflictPolicyFail)
+	require.NoError(t, err)
+	require.Equal(t, []fileConflictModel{}, conflicts)
+
+	t.Log("Fail - nothing is written")
+	writeTestFile(t, filepath.Join(plantDirPth, "README.md"), "local\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "v2\n", 0644)
+	err = growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Files edited locally since the last grow would be overwritten: README.md")
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "local\n")
+	testFileContent(t, filepath.Join(plantDirPth, ".gitignore"), "/build\n/local\n")
+
+	t.Log("Dry run - the conflict is reported, but it doesn't fail")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsDryRun: true}))
+
+	t.Log("Skip - the local version is kept, and it's still a conflict on the next grow")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{ConflictPolicy: config.ConflictPolicySkip}))
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "local\n")
+	testFileContent(t, filepath.Join(plantDirPth, ".gitignore"), "/build\n/local\n/dist\n")
+	require.Error(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+
+	t.Log("Backup, selected by the garden map's conflict_policies")
+	gardenMap.ConflictPolicies = []config.ConflictPolicyModel{{Pattern: "*.md", Policy: config.ConflictPolicyBackup}}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "v2\n")
+	backupPths, err := filepath.Glob(filepath.Join(plantDirPth, "README.md.*.garden-backup"))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(backupPths))
+	testFileContent(t, backupPths[0], "local\n")
+
+	t.Log("Force")
+	gardenMap.ConflictPolicies = nil
+	writeTestFile(t, filepath.Join(plantDirPth, "README.md"), "local again\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "v3\n", 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{ConflictPolicy: config.ConflictPolicyForce}))
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "v3\n")
+}
+
+func Test_growPlants_mergeConflicts(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	seedDirPth := filepath.Join(gardenDirPth, "seeds", "app")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "# App\n\nintro\n\nusage\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "logo.bin"), "logo\x00v1", 0644)
+
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app-1": config.PlantModel{Path: plantDirPth, Seed: "app"},
+		},
+	}
+	mergeOptions := growOptionsModel{ConflictPolicy: config.ConflictPolicyMerge}
+
+	t.Log("The first grow saves the merge bases of the text files")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	mergeBasesDirPth := config.PlantMergeBasesDirPath(gardenDirPth, "app-1")
+	testFileContent(t, filepath.Join(mergeBasesDirPth, "README.md"), "# App\n\nintro\n\nusage\n")
+	isExist, err := pathutil.IsPathExists(filepath.Join(mergeBasesDirPth, "logo.bin"))
+	require.NoError(t, err)
+	require.False(t, isExist)
+
+	t.Log("Clean merge - both the local and the seed changes are kept")
+	writeTestFile(t, filepath.Join(plantDirPth, "README.md"), "# App\n\nlocal intro\n\nusage\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "# App\n\nintro\n\nusage v2\n", 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, mergeOptions))
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "# App\n\nlocal intro\n\nusage v2\n")
+	testFileContent(t, filepath.Join(mergeBasesDirPth, "README.md"), "# App\n\nintro\n\nusage v2\n")
+
+	t.Log("The merged file is still a local edit on the next grow")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "# App v3\n\nintro\n\nusage v2\n", 0644)
+	require.Error(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, mergeOptions))
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "# App v3\n\nlocal intro\n\nusage v2\n")
+
+	t.Log("Overlapping changes - conflict markers are written")
+	writeTestFile(t, filepath.Join(plantDirPth, "README.md"), "# App v3\n\nlocal intro\n\nlocal usage\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "# App v3\n\nintro\n\nusage v4\n", 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, mergeOptions))
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"),
+		"# App v3\n\nlocal intro\n\n<<<<<<< local\nlocal usage\n=======\nusage v4\n>>>>>>> seed\n")
+
+	t.Log("Not a text file - backed up instead")
+	writeTestFile(t, filepath.Join(plantDirPth, "logo.bin"), "logo\x00local", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "logo.bin"), "logo\x00v2", 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, mergeOptions))
+	testFileContent(t, filepath.Join(plantDirPth, "logo.bin"), "logo\x00v2")
+	backupPths, err := filepath.Glob(filepath.Join(plantDirPth, "logo.bin.*.garden-backup"))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(backupPths))
+	testFileContent(t, backupPths[0], "logo\x00local")
+}
@@ -0,0 +1,340 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// copyActionType ...
+//  what happened to a path during a copy
+type copyActionType string
+
+const (
+	// copyActionCreated : the path didn't exist in the target dir
+	copyActionCreated copyActionType = "created"
+	// copyActionUpdated : the path's content, type or symlink target changed
+	copyActionUpdated copyActionType = "updated"
+	// copyActionModeChanged : only the path's permissions changed
+	copyActionModeChanged copyActionType = "mode changed"
+	// copyActionUnchanged : the path was already the same in the target dir
+	copyActionUnchanged copyActionType = "unchanged"
+)
+
+// copiedPathModel ...
+type copiedPathModel struct {
+	// Path : slash separated, relative to the copied dir
+	Path   string
+	Action copyActionType
+	IsDir  bool
+}
+
+// copyResultModel ...
+//  the copied paths, in walk (lexical) order
+type copyResultModel struct {
+	Paths []copiedPathModel
+}
+
+// Count ...
+//  the number of copied files and symlinks (directories are not counted)
+//  with the given action
+func (result copyResultModel) Count(action copyActionType) int {
+	count := 0
+	for _, aPath := range result.Paths {
+		if !aPath.IsDir && aPath.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// Changed ...
+//  the created and updated paths
+func (result copyResultModel) Changed() []copiedPathModel {
+	changed := []copiedPathModel{}
+	for _, aPath := range result.Paths {
+		if aPath.Action != copyActionUnchanged {
+			changed = append(changed, aPath)
+		}
+	}
+	return changed
+}
+
+// logCopyResult ...
+//  prints the created, updated and mode changed paths (in a dry run
+//  every path, with the action which would happen), and a summary
+func logCopyResult(result copyResultModel, dstDirPth string, isDryRun bool, logger *log.Logger) {
+	for _, aPath := range result.Paths {
+		pth := filepath.Join(dstDirPth, filepath.FromSlash(aPath.Path))
+		if isDryRun {
+			logger.Infof("    would be %-12s %s", aPath.Action, pth)
+			continue
+		}
+		if aPath.Action == copyActionUnchanged {
+			logger.Debugf("    %-12s %s", aPath.Action, pth)
+			continue
+		}
+		logger.Infof("    %-12s %s", aPath.Action, pth)
+	}
+	logger.Infof("    files: %d created, %d updated, %d mode changed, %d unchanged",
+		result.Count(copyActionCreated), result.Count(copyActionUpdated),
+		result.Count(copyActionModeChanged), result.Count(copyActionUnchanged))
+}
+
+// isSameFileContent ...
+//  quick check by size and modification time, if those differ
+//  (but the size is the same) the content's hash is compared
+func isSameFileContent(srcPth string, srcInfo os.FileInfo, dstPth string, dstInfo os.FileInfo) (bool, error) {
+	if srcInfo.Size() != dstInfo.Size() {
+		return false, nil
+	}
+	if srcInfo.ModTime().Equal(dstInfo.ModTime()) {
+		return true, nil
+	}
+	srcHash, err := fileSHA256(srcPth)
+	if err != nil {
+		return false, err
+	}
+	dstHash, err := fileSHA256(dstPth)
+	if err != nil {
+		return false, err
+	}
+	return srcHash == dstHash, nil
+}
+
+// copyFileContent ...
+//  writes the content into a temporary file next to the target,
+//  then renames it, so the target is never partially written
+func copyFileContent(srcPth, dstPth string, srcInfo os.FileInfo) error {
+	srcFile, err := os.Open(srcPth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := srcFile.Close(); err != nil {
+			log.Warnf("Failed to close file (path:%s), error: %s", srcPth, err)
+		}
+	}()
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(dstPth), "."+filepath.Base(dstPth)+".garden-tmp-")
+	if err != nil {
+		return err
+	}
+	tmpPth := tmpFile.Name()
+	_, copyErr := io.Copy(tmpFile, srcFile)
+	if err := tmpFile.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if copyErr == nil {
+		copyErr = os.Chmod(tmpPth, srcInfo.Mode().Perm())
+	}
+	if copyErr == nil {
+		copyErr = os.Chtimes(tmpPth, srcInfo.ModTime(), srcInfo.ModTime())
+	}
+	if copyErr == nil {
+		copyErr = os.Rename(tmpPth, dstPth)
+	}
+	if copyErr != nil {
+		if err := os.Remove(tmpPth); err != nil && !os.IsNotExist(err) {
+			log.Warnf("Failed to remove temporary file (path:%s), error: %s", tmpPth, err)
+		}
+		return copyErr
+	}
+	return nil
+}
+
+// isNonEmptyDir ...
+func isNonEmptyDir(pth string, fileInfo os.FileInfo) (bool, error) {
+	if !fileInfo.IsDir() {
+		return false, nil
+	}
+	fileInfos, err := ioutil.ReadDir(pth)
+	if err != nil {
+		return false, err
+	}
+	return len(fileInfos) > 0, nil
+}
+
+// copyPathAction ...
+//  what copying the directory, symlink or regular file would do
+//  with the target path, without changing anything.
+//  A non empty directory can't be replaced with a file or symlink.
+func copyPathAction(srcPth string, srcInfo os.FileInfo, dstPth string) (copyActionType, error) {
+	dstInfo, err := os.Lstat(dstPth)
+	if os.IsNotExist(err) {
+		return copyActionCreated, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if !srcInfo.IsDir() {
+		if isNonEmpty, err := isNonEmptyDir(dstPth, dstInfo); err != nil {
+			return "", err
+		} else if isNonEmpty {
+			return "", fmt.Errorf("Can't replace directory with a file, directory is not empty (path:%s)", dstPth)
+		}
+	}
+
+	switch {
+	case srcInfo.IsDir():
+		if !dstInfo.IsDir() {
+			return copyActionUpdated, nil
+		}
+	case srcInfo.Mode()&os.ModeSymlink != 0:
+		if dstInfo.Mode()&os.ModeSymlink == 0 {
+			return copyActionUpdated, nil
+		}
+		linkTarget, err := os.Readlink(srcPth)
+		if err != nil {
+			return "", err
+		}
+		dstLinkTarget, err := os.Readlink(dstPth)
+		if err != nil {
+			return "", err
+		}
+		if linkTarget != dstLinkTarget {
+			return copyActionUpdated, nil
+		}
+		// a symlink's permissions can't be changed
+		return copyActionUnchanged, nil
+	case srcInfo.Mode().IsRegular():
+		if !dstInfo.Mode().IsRegular() {
+			return copyActionUpdated, nil
+		}
+		isSame, err := isSameFileContent(srcPth, srcInfo, dstPth, dstInfo)
+		if err != nil {
+			return "", err
+		}
+		if !isSame {
+			return copyActionUpdated, nil
+		}
+	default:
+		return "", fmt.Errorf("Not a regular file, directory or symlink (path:%s)", srcPth)
+	}
+
+	if dstInfo.Mode().Perm() != srcInfo.Mode().Perm() {
+		return copyActionModeChanged, nil
+	}
+	return copyActionUnchanged, nil
+}
+
+// applyCopyPathAction ...
+//  copies the directory, symlink or regular file, as decided by copyPathAction
+func applyCopyPathAction(srcPth string, srcInfo os.FileInfo, dstPth string, action copyActionType) error {
+	switch action {
+	case copyActionUnchanged:
+		return nil
+	case copyActionModeChanged:
+		return os.Chmod(dstPth, srcInfo.Mode().Perm())
+	}
+
+	if action == copyActionUpdated {
+		dstInfo, err := os.Lstat(dstPth)
+		if err != nil {
+			return err
+		}
+		// a regular file is replaced by renaming the new content over it
+		if !srcInfo.Mode().IsRegular() || !dstInfo.Mode().IsRegular() {
+			if err := os.Remove(dstPth); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch {
+	case srcInfo.IsDir():
+		if err := os.Mkdir(dstPth, srcInfo.Mode().Perm()); err != nil {
+			return err
+		}
+		// the umask might have been applied
+		return os.Chmod(dstPth, srcInfo.Mode().Perm())
+	case srcInfo.Mode()&os.ModeSymlink != 0:
+		linkTarget, err := os.Readlink(srcPth)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(linkTarget, dstPth)
+	}
+	return copyFileContent(srcPth, dstPth, srcInfo)
+}
+
+// copyDirContent ...
+//  copies the content of the source dir into the target dir (the target
+//  dir is created if it doesn't exist), preserving the modes, the
+//  modification times of files and created directories, and symlinks.
+//  Files which are the same in the target dir are not touched.
+//  Paths of the target dir which are not in the source dir are kept.
+//  If isDryRun is true the target dir is not changed, only the actions
+//  which would happen are returned.
+func copyDirContent(srcDirPth, dstDirPth string, isDryRun bool) (copyResultModel, error) {
+	result := copyResultModel{Paths: []copiedPathModel{}}
+	if !isDryRun {
+		if err := os.MkdirAll(dstDirPth, 0755); err != nil {
+			return copyResultModel{}, err
+		}
+	}
+
+	// the modification time of a created directory changes
+	//  when its content is created, so it's set at the end
+	createdDirs := []string{}
+	createdDirInfos := map[string]os.FileInfo{}
+	// the dirs which would be created or would replace a file, in a dry run
+	newDirPths := map[string]bool{}
+	err := filepath.Walk(srcDirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPth, err := filepath.Rel(srcDirPth, pth)
+		if err != nil {
+			return err
+		}
+		if relPth == "." {
+			return nil
+		}
+		dstPth := filepath.Join(dstDirPth, relPth)
+
+		action := copyActionCreated
+		// in a dry run the new dirs don't exist yet,
+		//  so everything in them would be created
+		if !isDryRun || !newDirPths[filepath.Dir(dstPth)] {
+			if action, err = copyPathAction(pth, fileInfo, dstPth); err != nil {
+				return fmt.Errorf("Failed to copy (%s), error: %s", relPth, err)
+			}
+		}
+		if !isDryRun {
+			if err := applyCopyPathAction(pth, fileInfo, dstPth, action); err != nil {
+				return fmt.Errorf("Failed to copy (%s), error: %s", relPth, err)
+			}
+		}
+		if fileInfo.IsDir() && (action == copyActionCreated || action == copyActionUpdated) {
+			newDirPths[dstPth] = true
+			createdDirs = append(createdDirs, dstPth)
+			createdDirInfos[dstPth] = fileInfo
+		}
+		result.Paths = append(result.Paths, copiedPathModel{
+			Path:   filepath.ToSlash(relPth),
+			Action: action,
+			IsDir:  fileInfo.IsDir(),
+		})
+		return nil
+	})
+	if err != nil {
+		return copyResultModel{}, err
+	}
+
+	if isDryRun {
+		return result, nil
+	}
+	for idx := len(createdDirs) - 1; idx >= 0; idx-- {
+		dirPth := createdDirs[idx]
+		modTime := createdDirInfos[dirPth].ModTime()
+		if err := os.Chtimes(dirPth, modTime, modTime); err != nil {
+			return copyResultModel{}, err
+		}
+	}
+	return result, nil
+}
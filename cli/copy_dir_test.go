@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_copyDirContent(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	srcDir := filepath.Join(tmpDir, "src")
+	dstDir := filepath.Join(tmpDir, "dst")
+	modTime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+
+	writeTestFile(t, filepath.Join(srcDir, "README.md"), "readme\n", 0644)
+	writeTestFile(t, filepath.Join(srcDir, "bin", "run.sh"), "run\n", 0755)
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "empty"), 0700))
+	require.NoError(t, os.Symlink("bin/run.sh", filepath.Join(srcDir, "run")))
+	require.NoError(t, os.Chtimes(filepath.Join(srcDir, "README.md"), modTime, modTime))
+	require.NoError(t, os.Chtimes(filepath.Join(srcDir, "bin"), modTime, modTime))
+
+	t.Log("Copy into a new dir")
+	{
+		result, err := copyDirContent(srcDir, dstDir, false)
+		require.NoError(t, err)
+		require.Equal(t, []copiedPathModel{
+			{Path: "README.md", Action: copyActionCreated},
+			{Path: "bin", Action: copyActionCreated, IsDir: true},
+			{Path: "bin/run.sh", Action: copyActionCreated},
+			{Path: "empty", Action: copyActionCreated, IsDir: true},
+			{Path: "run", Action: copyActionCreated},
+		}, result.Paths)
+		require.Equal(t, 3, result.Count(copyActionCreated))
+
+		testFileContent(t, filepath.Join(dstDir, "README.md"), "readme\n")
+		testFilePermissions(t, filepath.Join(dstDir, "bin", "run.sh"), 0755)
+		testFilePermissions(t, filepath.Join(dstDir, "empty"), 0700)
+
+		fileInfo, err := os.Stat(filepath.Join(dstDir, "README.md"))
+		require.NoError(t, err)
+		require.True(t, modTime.Equal(fileInfo.ModTime()))
+		dirInfo, err := os.Stat(filepath.Join(dstDir, "bin"))
+		require.NoError(t, err)
+		require.True(t, modTime.Equal(dirInfo.ModTime()))
+
+		linkTarget, err := os.Readlink(filepath.Join(dstDir, "run"))
+		require.NoError(t, err)
+		require.Equal(t, "bin/run.sh", linkTarget)
+	}
+
+	t.Log("Copy again - nothing changed")
+	{
+		result, err := copyDirContent(srcDir, dstDir, false)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(result.Changed()))
+		require.Equal(t, 3, result.Count(copyActionUnchanged))
+	}
+
+	t.Log("Same content with a different modification time is unchanged")
+	{
+		require.NoError(t, os.Chtimes(filepath.Join(dstDir, "README.md"), time.Now(), time.Now()))
+		result, err := copyDirContent(srcDir, dstDir, false)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(result.Changed()))
+	}
+
+	t.Log("Updates")
+	{
+		writeTestFile(t, filepath.Join(srcDir, "README.md"), "changed\n", 0644)
+		require.NoError(t, os.Chmod(filepath.Join(srcDir, "bin", "run.sh"), 0700))
+		require.NoError(t, os.Remove(filepath.Join(srcDir, "run")))
+		require.NoError(t, os.Symlink("README.md", filepath.Join(srcDir, "run")))
+		writeTestFile(t, filepath.Join(dstDir, "plant-only.txt"), "kept\n", 0644)
+
+		result, err := copyDirContent(srcDir, dstDir, false)
+		require.NoError(t, err)
+		require.Equal(t, []copiedPathModel{
+			{Path: "README.md", Action: copyActionUpdated},
+			{Path: "bin/run.sh", Action: copyActionModeChanged},
+			{Path: "run", Action: copyActionUpdated},
+		}, result.Changed())
+
+		testFileContent(t, filepath.Join(dstDir, "README.md"), "changed\n")
+		testFilePermissions(t, filepath.Join(dstDir, "bin", "run.sh"), 0700)
+		linkTarget, err := os.Readlink(filepath.Join(dstDir, "run"))
+		require.NoError(t, err)
+		require.Equal(t, "README.md", linkTarget)
+		testFileContent(t, filepath.Join(dstDir, "plant-only.txt"), "kept\n")
+	}
+
+	t.Log("Dry run")
+	{
+		writeTestFile(t, filepath.Join(srcDir, "README.md"), "dry run\n", 0644)
+		writeTestFile(t, filepath.Join(srcDir, "new", "sub", "file.txt"), "new\n", 0644)
+
+		result, err := copyDirContent(srcDir, dstDir, true)
+		require.NoError(t, err)
+		require.Equal(t, []copiedPathModel{
+			{Path: "README.md", Action: copyActionUpdated},
+			{Path: "new", Action: copyActionCreated, IsDir: true},
+			{Path: "new/sub", Action: copyActionCreated, IsDir: true},
+			{Path: "new/sub/file.txt", Action: copyActionCreated},
+		}, result.Changed())
+
+		// nothing is changed
+		testFileContent(t, filepath.Join(dstDir, "README.md"), "changed\n")
+		isExist, err := pathutil.IsPathExists(filepath.Join(dstDir, "new"))
+		require.NoError(t, err)
+		require.False(t, isExist)
+
+		t.Log("Dry run into a dir which doesn't exist")
+		result, err = copyDirContent(srcDir, filepath.Join(tmpDir, "not-exist"), true)
+		require.NoError(t, err)
+		require.Equal(t, len(result.Paths), len(result.Changed()))
+		isExist, err = pathutil.IsPathExists(filepath.Join(tmpDir, "not-exist"))
+		require.NoError(t, err)
+		require.False(t, isExist)
+	}
+
+	t.Log("A non empty directory can't be replaced with a file")
+	{
+		writeTestFile(t, filepath.Join(srcDir, "conf"), "file\n", 0644)
+		writeTestFile(t, filepath.Join(dstDir, "conf", "a.txt"), "a\n", 0644)
+		_, err := copyDirContent(srcDir, dstDir, true)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "directory is not empty")
+		_, err = copyDirContent(srcDir, dstDir, false)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "directory is not empty")
+	}
+}
@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/codegangsta/cli"
+)
+
+const (
+	// diffPlantLabel : the path prefix of the plant's current files in the diffs
+	diffPlantLabel = "plant"
+	// diffRenderedLabel : the path prefix of the rendered files in the diffs
+	diffRenderedLabel = "rendered"
+	// diffStatMaxBarWidth : the max width of the +/- bar of --stat
+	diffStatMaxBarWidth = 40
+	// diffDiffersExitCode : the exit code of --exit-code if any plant differs
+	diffDiffersExitCode = 1
+	// diffErrorExitCode : the exit code if the plants can't be diffed,
+	//  distinct from diffDiffersExitCode
+	diffErrorExitCode = 2
+)
+
+// plantDiffResultModel ...
+type plantDiffResultModel struct {
+	PlantID   string
+	PlantPath string
+	Diffs     []dirTreeDiffModel
+}
+
+// diffPlants ...
+//  renders the plants into temporary dirs and compares them with
+//  the plant dirs, the same way a grow with the conflict policy would change
+//  them: the locally edited files a grow would skip are not reported, and
+//  the files a previous grow wrote (recorded in the garden state), but the
+//  seed no longer renders are reported as removed, unless they were
+//  edited locally (prune keeps those). The other files of the plant dir
+//  are not managed by garden, and are ignored. The garden lock is not changed.
+func diffPlants(gardenDirAbsPth string, gardenMap config.GardenMapModel, plantIDs []string, conflictPolicy string) ([]plantDiffResultModel, error) {
+	gardenLock, err := config.LoadGardenLock(gardenDirAbsPth)
+	if err != nil {
+		return []plantDiffResultModel{}, fmt.Errorf("Failed to load garden lock, error: %s", err)
+	}
+	warnIfGardenLockIsStale(gardenMap, gardenLock)
+
+	gardenState, err := config.LoadGardenState(gardenDirAbsPth)
+	if err != nil {
+		return []plantDiffResultModel{}, fmt.Errorf("Failed to load garden state, error: %s", err)
+	}
+
+	growRun := growRunModel{
+		Host:      createTemplateHostModel(),
+		Timestamp: time.Now(),
+		Lock:      gardenLock,
+	}
+	results := []plantDiffResultModel{}
+	for _, aPlantID := range plantIDs {
+		result, err := diffPlant(aPlantID, gardenMap, gardenDirAbsPth, growRun, gardenState.PlantState(aPlantID), conflictPolicy)
+		if err != nil {
+			return []plantDiffResultModel{}, fmt.Errorf("Failed to diff plant (%s), error: %s", aPlantID, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func diffPlant(plantID string, gardenMap config.GardenMapModel, gardenDirAbsPth string, growRun growRunModel, plantState config.PlantStateModel, conflictPolicy string) (plantDiffResultModel, error) {
+	log.Infof("==> diffing plant: %s", plantID)
+	renderedDirPth, err := ioutil.TempDir("", "garden-diff-")
+	if err != nil {
+		return plantDiffResultModel{}, err
+	}
+	defer func() {
+		if err := os.RemoveAll(renderedDirPth); err != nil {
+			log.Warnf("Failed to remove temp dir (path:%s), error: %s", renderedDirPth, err)
+		}
+	}()
+
+	absPlantPath, renderedSeed, err := renderPlant(plantID, gardenMap, gardenDirAbsPth, growRun, renderedDirPth)
+	if err != nil {
+		return plantDiffResultModel{}, err
+	}
+	conflicts, err := detectConflicts(renderedDirPth, absPlantPath, plantState, renderedSeed, gardenMap, conflictPolicy)
+	if err != nil {
+		return plantDiffResultModel{}, fmt.Errorf("Failed to check local changes, error: %s", err)
+	}
+	for _, aConflict := range conflicts {
+		log.Warnf(" -> %s was edited locally (on conflict: %s)", aConflict.Path, aConflict.Policy)
+	}
+
+	diffs, err := diffDirTrees(absPlantPath, renderedDirPth, diffPlantLabel, diffRenderedLabel)
+	if err != nil {
+		return plantDiffResultModel{}, err
+	}
+	diffs, err = filterManagedDiffs(diffs, renderedDirPth, absPlantPath, plantState, conflicts)
+	if err != nil {
+		return plantDiffResultModel{}, err
+	}
+	return plantDiffResultModel{PlantID: plantID, PlantPath: absPlantPath, Diffs: diffs}, nil
+}
+
+// filterManagedDiffs ...
+//  drops the diffs of the plant's files which are not rendered and not
+//  recorded in the plant's state either (the files not managed by garden),
+//  and of the conflicts a grow would skip; a recorded file is removed only
+//  if a grow would prune it
+func filterManagedDiffs(diffs []dirTreeDiffModel, renderedDirPth, plantDirPth string, plantState config.PlantStateModel, conflicts []fileConflictModel) ([]dirTreeDiffModel, error) {
+	renderedFileHashes, err := collectFileHashes(renderedDirPth)
+	if err != nil {
+		return []dirTreeDiffModel{}, err
+	}
+	recordedFiles, err := classifyRecordedFiles(plantDirPth, plantState, pruneCandidatePaths(plantState, renderedFileHashes, conflicts))
+	if err != nil {
+		return []dirTreeDiffModel{}, err
+	}
+	removedPths := map[string]bool{}
+	for _, aPth := range recordedFiles.Unchanged {
+		removedPths[aPth] = true
+	}
+	skippedPths := map[string]bool{}
+	for _, aConflict := range conflicts {
+		if aConflict.Policy == config.ConflictPolicySkip {
+			skippedPths[aConflict.Path] = true
+		}
+	}
+
+	filteredDiffs := []dirTreeDiffModel{}
+	for _, aDiff := range diffs {
+		if skippedPths[aDiff.Path] {
+			continue
+		}
+		if !removedPths[aDiff.Path] {
+			if _, err := os.Lstat(filepath.Join(renderedDirPth, filepath.FromSlash(aDiff.Path))); os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return []dirTreeDiffModel{}, err
+			}
+		}
+		filteredDiffs = append(filteredDiffs, aDiff)
+	}
+	return filteredDiffs, nil
+}
+
+// formatDiffStat ...
+//  git diff --stat like summary of the results
+func formatDiffStat(results []plantDiffResultModel) string {
+	type statLineModel struct {
+		Path       string
+		Insertions int
+		Deletions  int
+	}
+	statLines := []statLineModel{}
+	maxPathLen, maxChanges := 0, 0
+	for _, aResult := range results {
+		for _, aDiff := range aResult.Diffs {
+			statLine := statLineModel{
+				Path:       aResult.PlantID + "/" + aDiff.Path,
+				Insertions: aDiff.Insertions,
+				Deletions:  aDiff.Deletions,
+			}
+			if len(statLine.Path) > maxPathLen {
+				maxPathLen = len(statLine.Path)
+			}
+			if changes := statLine.Insertions + statLine.Deletions; changes > maxChanges {
+				maxChanges = changes
+			}
+			statLines = append(statLines, statLine)
+		}
+	}
+
+	stat := ""
+	insertions, deletions := 0, 0
+	for _, aStatLine := range statLines {
+		insertions += aStatLine.Insertions
+		deletions += aStatLine.Deletions
+		plusCount, minusCount := aStatLine.Insertions, aStatLine.Deletions
+		if maxChanges > diffStatMaxBarWidth {
+			plusCount = plusCount * diffStatMaxBarWidth / maxChanges
+			minusCount = minusCount * diffStatMaxBarWidth / maxChanges
+		}
+		stat += fmt.Sprintf(" %-*s | %d %s%s\n", maxPathLen, aStatLine.Path,
+			aStatLine.Insertions+aStatLine.Deletions, strings.Repeat("+", plusCount), strings.Repeat("-", minusCount))
+	}
+	stat += fmt.Sprintf(" %d file(s) changed, %d insertion(s)(+), %d deletion(s)(-)\n", len(statLines), insertions, deletions)
+	return stat
+}
+
+// diffFatalf ...
+//  like log.Fatalf, but exits with diffErrorExitCode, so an error
+//  can be told apart from differing plants (--exit-code)
+func diffFatalf(format string, args ...interface{}) {
+	log.Errorf(format, args...)
+	os.Exit(diffErrorExitCode)
+}
+
+func diff(c *cli.Context) {
+	log.Infoln("Diff")
+
+	conflictPolicy := c.String(OnConflictKey)
+	if conflictPolicy == "" {
+		conflictPolicy = config.ConflictPolicyFail
+	}
+	if !config.IsValidConflictPolicy(conflictPolicy) {
+		diffFatalf("Invalid --%s policy (%s), options: %s, %s, %s, %s, %s", OnConflictKey, conflictPolicy,
+			config.ConflictPolicyFail, config.ConflictPolicySkip, config.ConflictPolicyBackup, config.ConflictPolicyForce, config.ConflictPolicyMerge)
+	}
+
+	gardenMap, gardenDirAbsPth, err := config.LoadGardenMap("")
+	if err != nil {
+		diffFatalf("Failed to load Garden Map: %s", err)
+	}
+
+	plantIDs := gardenMap.FilteredPlantsIDs(WorkWithPlantID, WorkWithZone)
+	if len(plantIDs) < 1 {
+		diffFatalf("No plants to diff!")
+	}
+	results, err := diffPlants(gardenDirAbsPth, gardenMap, plantIDs, conflictPolicy)
+	if err != nil {
+		diffFatalf("Failed to diff plants: %s", err)
+	}
+
+	changedPlantCount := 0
+	for _, aResult := range results {
+		if len(aResult.Diffs) > 0 {
+			changedPlantCount++
+		}
+	}
+
+	fmt.Println()
+	if c.Bool(StatKey) {
+		fmt.Print(formatDiffStat(results))
+	} else {
+		for _, aResult := range results {
+			if len(aResult.Diffs) == 0 {
+				continue
+			}
+			fmt.Printf("# plant: %s (%s)\n", aResult.PlantID, aResult.PlantPath)
+			for _, aDiff := range aResult.Diffs {
+				fmt.Println(strings.TrimSuffix(aDiff.Diff, "\n"))
+			}
+		}
+	}
+
+	if changedPlantCount == 0 {
+		log.Infoln("No differences")
+		return
+	}
+	log.Infof("%d of %d plant(s) differ", changedPlantCount, len(results))
+	if c.Bool(ExitCodeKey) {
+		os.Exit(diffDiffersExitCode)
+	}
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_diffPlants(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	seedDirPth := filepath.Join(gardenDirPth, "seeds", "app")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md.template"), "# {{ var \"AppName\" }}\nManaged by garden.\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "removed.txt"), "removed\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "edited.txt"), "edited\n", 0644)
+
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app-1": config.PlantModel{Path: plantDirPth, Seed: "app", Vars: config.PlantVarsMap{"AppName": "my-app"}},
+		},
+	}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+
+	t.Log("The seed no longer renders removed.txt and edited.txt, the files not written by garden are ignored")
+	require.NoError(t, os.Remove(filepath.Join(seedDirPth, "removed.txt")))
+	require.NoError(t, os.Remove(filepath.Join(seedDirPth, "edited.txt")))
+	// edited locally: prune keeps it, it's not removed
+	writeTestFile(t, filepath.Join(plantDirPth, "edited.txt"), "edited locally\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "new.txt"), "new\n", 0644)
+	writeTestFile(t, filepath.Join(plantDirPth, "README.md"), "# old\nManaged by garden.\n", 0644)
+	writeTestFile(t, filepath.Join(plantDirPth, "unmanaged.txt"), "unmanaged\n", 0644)
+	writeTestFile(t, filepath.Join(plantDirPth, ".git", "HEAD"), "ref: refs/heads/master\n", 0644)
+
+	results, err := diffPlants(gardenDirPth, gardenMap, []string{"app-1"}, config.ConflictPolicyFail)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(results))
+	require.Equal(t, "app-1", results[0].PlantID)
+	require.Equal(t, []dirTreeDiffModel{
+		{
+			Path:       "README.md",
+			Diff:       "--- plant/README.md\n+++ rendered/README.md\n@@ -1,2 +1,2 @@\n-# old\n+# my-app\n Managed by garden.\n",
+			Insertions: 1,
+			Deletions:  1,
+		},
+		{
+			Path:       "new.txt",
+			Diff:       "--- /dev/null\n+++ rendered/new.txt\n@@ -0,0 +1 @@\n+new\n",
+			Insertions: 1,
+		},
+		{
+			Path:      "removed.txt",
+			Diff:      "--- plant/removed.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-removed\n",
+			Deletions: 1,
+		},
+	}, results[0].Diffs)
+
+	t.Log("The plant dir is not changed")
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "# old\nManaged by garden.\n")
+
+	t.Log("The locally edited files a grow would skip are not shown")
+	skipResults, err := diffPlants(gardenDirPth, gardenMap, []string{"app-1"}, config.ConflictPolicySkip)
+	require.NoError(t, err)
+	diffPths := []string{}
+	for _, aDiff := range skipResults[0].Diffs {
+		diffPths = append(diffPths, aDiff.Path)
+	}
+	require.Equal(t, []string{"new.txt", "removed.txt"}, diffPths)
+
+	t.Log("Stat")
+	require.Equal(t, ` app-1/README.md   | 2 +-
+ app-1/new.txt     | 1 +
+ app-1/removed.txt | 1 -
+ 3 file(s) changed, 2 insertion(s)(+), 2 deletion(s)(-)
+`, formatDiffStat(results))
+
+	t.Log("No differences after the grow")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{ConflictPolicy: config.ConflictPolicyForce, IsPrune: true}))
+	testFileContent(t, filepath.Join(plantDirPth, "unmanaged.txt"), "unmanaged\n")
+	testFileContent(t, filepath.Join(plantDirPth, "edited.txt"), "edited locally\n")
+	results, err = diffPlants(gardenDirPth, gardenMap, []string{"app-1"}, config.ConflictPolicyFail)
+	require.NoError(t, err)
+	require.Equal(t, []dirTreeDiffModel{}, results[0].Diffs)
+}
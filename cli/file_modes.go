@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// fileModeExtensions : file extensions which select the file mode,
+//  the extension is removed from the file's name,
+//  e.g. ".gitignore.garden-append" appends to ".gitignore".
+//  The extensions are namespaced, so a seed's e.g. ".patch" file
+//  is copied as any other file.
+var fileModeExtensions = map[string]string{
+	".garden-append": config.FileModeAppend,
+	".garden-patch":  config.FileModePatch,
+	".garden-merge":  config.FileModeMerge,
+}
+
+// fileModeForPath ...
+//  returns the file mode and the target path (relative to the seed/plant root)
+//  for a seed relative (slash separated) file path
+func fileModeForPath(relPth string, seedConfig config.SeedConfigModel) (string, string) {
+	if mode, isFound := fileModeExtensions[filepath.Ext(relPth)]; isFound {
+		return mode, strings.TrimSuffix(relPth, filepath.Ext(relPth))
+	}
+	return seedConfig.FileModeForPath(relPth), relPth
+}
+
+// appendMissingLines ...
+//  appends the lines which are not yet included in the content
+func appendMissingLines(content, linesToAppend string) string {
+	existingLines, _ := splitLines(content)
+	isExistingLine := map[string]bool{}
+	for _, aLine := range existingLines {
+		isExistingLine[aLine] = true
+	}
+
+	newLines, _ := splitLines(linesToAppend)
+	missingLines := []string{}
+	for _, aLine := range newLines {
+		if !isExistingLine[aLine] {
+			missingLines = append(missingLines, aLine)
+			isExistingLine[aLine] = true
+		}
+	}
+	if len(missingLines) == 0 {
+		return content
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + joinLines(missingLines, true)
+}
+
+// applyFileMode ...
+//  returns the content which should be written into the plant's file
+func applyFileMode(mode, targetPth, currentContent, seedContent string) (string, error) {
+	switch mode {
+	case config.FileModeOverwrite:
+		return seedContent, nil
+	case config.FileModeAppend:
+		return appendMissingLines(currentContent, seedContent), nil
+	case config.FileModePatch:
+		return applyUnifiedDiff(currentContent, seedContent)
+	case config.FileModeMerge:
+		return mergeStructuredContent(targetPth, currentContent, seedContent)
+	}
+	return "", fmt.Errorf("Unknown file mode: %s", mode)
+}
+
+// applyFileModesInDir ...
+//  handles the files of the (already evaluated) seed dir which should
+//  not simply overwrite the plant's files: the seed file is replaced
+//  with the result of the mode (append, patch or merge), applied on
+//  the plant's current file. Returns the (slash separated, relative)
+//  paths of the files which were written this way.
+func applyFileModesInDir(seedDirPth, plantDirPth string, seedConfig config.SeedConfigModel, logger *log.Logger) ([]string, error) {
+	type fileModeItem struct {
+		Pth       string
+		Rel       string
+		TargetRel string
+		Mode      string
+	}
+	items := []fileModeItem{}
+	seedFiles := map[string]bool{}
+
+	err := filepath.Walk(seedDirPth, func(pth string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !f.Mode().IsRegular() {
+			return nil
+		}
+		relPth, err := filepath.Rel(seedDirPth, pth)
+		if err != nil {
+			return err
+		}
+		relPth = filepath.ToSlash(relPth)
+		seedFiles[relPth] = true
+
+		mode, targetRel := fileModeForPath(relPth, seedConfig)
+		if mode != config.FileModeOverwrite {
+			items = append(items, fileModeItem{Pth: pth, Rel: relPth, TargetRel: targetRel, Mode: mode})
+		}
+		return nil
+	})
+	if err != nil {
+		return []string{}, fmt.Errorf("Failed to scan seed directory (path:%s), error: %s", seedDirPth, err)
+	}
+
+	appliedPaths := []string{}
+	for _, anItem := range items {
+		if anItem.TargetRel != anItem.Rel && seedFiles[anItem.TargetRel] {
+			return []string{}, fmt.Errorf("Both %s and %s are defined in the seed, can't %s", anItem.TargetRel, anItem.Rel, anItem.Mode)
+		}
+
+		logger.Infof(" -> %s: %s", anItem.Mode, anItem.TargetRel)
+		seedContent, err := fileutil.ReadStringFromFile(anItem.Pth)
+		if err != nil {
+			return []string{}, fmt.Errorf("Failed to read file (path:%s), error: %s", anItem.Pth, err)
+		}
+		perms, err := fileutil.GetFilePermissions(anItem.Pth)
+		if err != nil {
+			return []string{}, fmt.Errorf("Failed to get permissions of file (path:%s), error: %s", anItem.Pth, err)
+		}
+
+		currentContent := ""
+		plantFilePth := filepath.Join(plantDirPth, filepath.FromSlash(anItem.TargetRel))
+		if isExist, err := pathutil.IsPathExists(plantFilePth); err != nil {
+			return []string{}, err
+		} else if isExist {
+			currentContent, err = fileutil.ReadStringFromFile(plantFilePth)
+			if err != nil {
+				return []string{}, fmt.Errorf("Failed to read plant file (path:%s), error: %s", plantFilePth, err)
+			}
+			perms, err = fileutil.GetFilePermissions(plantFilePth)
+			if err != nil {
+				return []string{}, fmt.Errorf("Failed to get permissions of file (path:%s), error: %s", plantFilePth, err)
+			}
+		}
+
+		resultContent, err := applyFileMode(anItem.Mode, anItem.TargetRel, currentContent, seedContent)
+		if err != nil {
+			return []string{}, fmt.Errorf("Failed to %s %s, error: %s", anItem.Mode, anItem.TargetRel, err)
+		}
+
+		if err := os.Remove(anItem.Pth); err != nil {
+			return []string{}, fmt.Errorf("Failed to remove file (path:%s), error: %s", anItem.Pth, err)
+		}
+		targetPth := filepath.Join(seedDirPth, filepath.FromSlash(anItem.TargetRel))
+		if err := fileutil.WriteStringToFileWithPermission(targetPth, resultContent, perms); err != nil {
+			return []string{}, fmt.Errorf("Failed to write file (path:%s), error: %s", targetPth, err)
+		}
+		appliedPaths = append(appliedPaths, anItem.TargetRel)
+	}
+
+	return appliedPaths, nil
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_appendMissingLines(t *testing.T) {
+	require.Equal(t, "a\nb\n", appendMissingLines("", "a\nb\n"))
+	require.Equal(t, "a\nb\nc\n", appendMissingLines("a\nb\n", "b\nc\n"))
+	require.Equal(t, "a\nc\n", appendMissingLines("a", "c"))
+	require.Equal(t, "a\nb\n", appendMissingLines("a\nb\n", "a\n"))
+}
+
+func Test_fileModeForPath(t *testing.T) {
+	seedConfig := config.SeedConfigModel{
+		FileModes: []config.FileModeModel{
+			{Pattern: ".gitignore", Mode: config.FileModeAppend},
+			{Pattern: "config/*.json", Mode: config.FileModeMerge},
+		},
+	}
+
+	mode, target := fileModeForPath("sub/.gitignore.garden-append", config.SeedConfigModel{})
+	require.Equal(t, config.FileModeAppend, mode)
+	require.Equal(t, "sub/.gitignore", target)
+
+	mode, target = fileModeForPath("README.md.garden-patch", config.SeedConfigModel{})
+	require.Equal(t, config.FileModePatch, mode)
+	require.Equal(t, "README.md", target)
+
+	t.Log("Plain extensions are not file modes")
+	for _, aPth := range []string{"patches/fix.patch", "notes.append", "data.merge"} {
+		mode, target = fileModeForPath(aPth, config.SeedConfigModel{})
+		require.Equal(t, config.FileModeOverwrite, mode, aPth)
+		require.Equal(t, aPth, target, aPth)
+	}
+
+	mode, target = fileModeForPath("config/app.json", seedConfig)
+	require.Equal(t, config.FileModeMerge, mode)
+	require.Equal(t, "config/app.json", target)
+
+	mode, target = fileModeForPath(".gitignore", seedConfig)
+	require.Equal(t, config.FileModeAppend, mode)
+	require.Equal(t, ".gitignore", target)
+
+	mode, target = fileModeForPath("app.json", seedConfig)
+	require.Equal(t, config.FileModeOverwrite, mode)
+	require.Equal(t, "app.json", target)
+}
+
+func Test_applyFileModesInDir(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	seedDir := filepath.Join(tmpDir, "seed")
+	plantDir := filepath.Join(tmpDir, "plant")
+	require.NoError(t, os.MkdirAll(filepath.Join(seedDir, "config"), 0777))
+	require.NoError(t, os.MkdirAll(plantDir, 0777))
+
+	// seed
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(seedDir, ".gitignore.garden-append"), "/build\n/.garden\n"))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(seedDir, "config", "app.json"), `{"debug": true}`))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(seedDir, "README.md.garden-patch"), `@@ -1,1 +1,2 @@
+ # My App
++Managed by garden.
+`))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(seedDir, "new.txt.garden-append"), "new\n"))
+	require.NoError(t, os.MkdirAll(filepath.Join(seedDir, "patches"), 0777))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(seedDir, "patches", "fix.patch"), "@@ -1 +1 @@\n-a\n+b\n"))
+	// plant
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(plantDir, ".gitignore"), "/build\n"))
+	require.NoError(t, fileutil.WriteStringToFileWithPermission(filepath.Join(plantDir, "README.md"), "# My App\n", 0600))
+
+	seedConfig := config.SeedConfigModel{
+		FileModes: []config.FileModeModel{
+			{Pattern: "config/*.json", Mode: config.FileModeMerge},
+		},
+	}
+	appliedPaths, err := applyFileModesInDir(seedDir, plantDir, seedConfig, log.StandardLogger())
+	require.NoError(t, err)
+	require.Equal(t, []string{".gitignore", "README.md", "config/app.json", "new.txt"}, appliedPaths)
+
+	testFileContent(t, filepath.Join(seedDir, ".gitignore"), "/build\n/.garden\n")
+	testFileContent(t, filepath.Join(seedDir, "README.md"), "# My App\nManaged by garden.\n")
+	testFileContent(t, filepath.Join(seedDir, "config", "app.json"), "{\n  \"debug\": true\n}\n")
+	testFileContent(t, filepath.Join(seedDir, "new.txt"), "new\n")
+	// a plain .patch file is not applied, it's copied as it is
+	testFileContent(t, filepath.Join(seedDir, "patches", "fix.patch"), "@@ -1 +1 @@\n-a\n+b\n")
+	isExist, err := pathutil.IsPathExists(filepath.Join(seedDir, "patches", "fix"))
+	require.NoError(t, err)
+	require.False(t, isExist)
+	// the plant file's permissions are kept
+	perms, err := fileutil.GetFilePermissions(filepath.Join(seedDir, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), perms)
+	// mode files are removed
+	for _, aPth := range []string{".gitignore.garden-append", "README.md.garden-patch", "new.txt.garden-append"} {
+		isExist, err := pathutil.IsPathExists(filepath.Join(seedDir, aPth))
+		require.NoError(t, err)
+		require.False(t, isExist, aPth)
+	}
+
+	t.Log("Both the target and the mode file are in the seed")
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(seedDir, ".gitignore.garden-append"), "/tmp\n"))
+	_, err = applyFileModesInDir(seedDir, plantDir, config.SeedConfigModel{}, log.StandardLogger())
+	require.EqualError(t, err, "Both .gitignore and .gitignore.garden-append are defined in the seed, can't append")
+}
@@ -1,17 +1,20 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/bitrise-io/go-utils/cmdex"
 	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
-	"github.com/bitrise-io/go-utils/templateutil"
 	"github.com/bitrise-io/garden/config"
 	"github.com/codegangsta/cli"
 )
@@ -20,13 +23,12 @@ import (
 //  it'll evalutate the content of the template file
 //  and then write it into a new file, without the .template extension
 //  and remove the original template file
-func evaluateAndReplaceTemplateFile(templateFilePath string, templateInventory GardenTemplateInventoryModel) error {
+func evaluateAndReplaceTemplateFile(templateFilePath string, evalContext templateEvaluationContextModel) error {
 	fileContent, err := fileutil.ReadStringFromFile(templateFilePath)
 	if err != nil {
 		return fmt.Errorf("Failed to read template file (path:%s), error: %s", templateFilePath, err)
 	}
-	evaluatedContent, err := templateutil.EvaluateTemplateStringToString(fileContent, templateInventory,
-		createAvailableTemplateFunctions(templateInventory))
+	evaluatedContent, err := evaluateTemplateString(templateFilePath, fileContent, evalContext)
 	if err != nil {
 		return fmt.Errorf("Failed to evaluate template (path:%s), error: %s", templateFilePath, err)
 	}
@@ -36,7 +38,7 @@ func evaluateAndReplaceTemplateFile(templateFilePath string, templateInventory G
 	if err != nil {
 		return fmt.Errorf("Failed to get permission settings of the original template file, error: %s", err)
 	}
-	log.Println("Writing evaluated template content into file:", evaluatedFileSavePth)
+	evalContext.logger().Println("Writing evaluated template content into file:", evaluatedFileSavePth)
 
 	if err := fileutil.WriteStringToFileWithPermission(evaluatedFileSavePth, evaluatedContent, origFilePerms); err != nil {
 		return fmt.Errorf("Failed to write evaluated content into file (path:%s), error: %s", evaluatedFileSavePth, err)
@@ -49,16 +51,21 @@ func evaluateAndReplaceTemplateFile(templateFilePath string, templateInventory G
 	return nil
 }
 
-func replaceTemplateFilesInDir(dirPth string, templateInventory GardenTemplateInventoryModel) error {
+func replaceTemplateFilesInDir(dirPth string, evalContext templateEvaluationContextModel) error {
 	templateFilePaths := []string{}
 	err := filepath.Walk(dirPth, func(pth string, f os.FileInfo, err error) error {
 		if f.Mode().IsDir() {
-			log.Debugf("-> (i) Path is directory, skipping: %s", pth)
+			evalContext.logger().Debugf("-> (i) Path is directory, skipping: %s", pth)
 			return nil
 		}
-		log.Debugf("-> Checking path: %s / ext: %s", pth, filepath.Ext(pth))
+		if f.Mode()&os.ModeSymlink != 0 {
+			// symlinks are preserved as they are, even if they point to a template
+			evalContext.logger().Debugf("-> (i) Path is symlink, skipping: %s", pth)
+			return nil
+		}
+		evalContext.logger().Debugf("-> Checking path: %s / ext: %s", pth, filepath.Ext(pth))
 		if filepath.Ext(pth) == ".template" {
-			log.Debugln(colorstring.Cyanf("--> Template Found! : %s", pth))
+			evalContext.logger().Debugln(colorstring.Cyanf("--> Template Found! : %s", pth))
 			templateFilePaths = append(templateFilePaths, pth)
 		}
 		return nil
@@ -67,11 +74,11 @@ func replaceTemplateFilesInDir(dirPth string, templateInventory GardenTemplateIn
 		return fmt.Errorf("Failed to scan template files in directory (path:%s), error: %s", dirPth, err)
 	}
 
-	log.Infoln(colorstring.Cyan("-> templateFilePaths:"), templateFilePaths)
+	evalContext.logger().Infoln(colorstring.Cyan("-> templateFilePaths:"), templateFilePaths)
 
 	for _, aTemplateFilePth := range templateFilePaths {
-		log.Infoln(colorstring.Cyan("-> Evaluating and replacing template file:"), aTemplateFilePth)
-		if err := evaluateAndReplaceTemplateFile(aTemplateFilePth, templateInventory); err != nil {
+		evalContext.logger().Infoln(colorstring.Cyan("-> Evaluating and replacing template file:"), aTemplateFilePth)
+		if err := evaluateAndReplaceTemplateFile(aTemplateFilePth, evalContext); err != nil {
 			return fmt.Errorf("Failed to evaluate template file (path:%s), error: %s", aTemplateFilePth, err)
 		}
 	}
@@ -79,85 +86,743 @@ func replaceTemplateFilesInDir(dirPth string, templateInventory GardenTemplateIn
 	return nil
 }
 
-func growPlant(plantID string, gardenMap config.GardenMapModel, gardenDirAbsPth string) error {
-	fmt.Println()
-	log.Println(colorstring.Yellow("==> growing plant:"), colorstring.Green(plantID))
-	log.Println("🌱")
+// evaluatePathTemplates ...
+//  evaluates the template actions in the names of the dir's files and
+//  directories (e.g. `{{ var "AppName" }}.go.template`) and renames them
+//  to the evaluated name, before the templates are evaluated
+func evaluatePathTemplates(dirPth string, evalContext templateEvaluationContextModel) error {
+	leftDelimiter := evalContext.Delimiters.Left
+	if leftDelimiter == "" {
+		leftDelimiter = "{{"
+	}
 
-	plantModel, isFound := gardenMap.Plants[plantID]
-	if !isFound {
-		return fmt.Errorf("growPlant: can't find Plant with ID: %s", plantID)
+	templatePths := []string{}
+	err := filepath.Walk(dirPth, func(pth string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if pth != dirPth && strings.Contains(f.Name(), leftDelimiter) {
+			templatePths = append(templatePths, pth)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to scan directory (path:%s), error: %s", dirPth, err)
+	}
+
+	// the walk lists a dir before its content: in reverse order the
+	//  content is renamed first, while the dir's path is still valid
+	for idx := len(templatePths) - 1; idx >= 0; idx-- {
+		pth := templatePths[idx]
+		evaluatedName, err := evaluateTemplateString(pth, filepath.Base(pth), evalContext)
+		if err != nil {
+			return fmt.Errorf("Failed to evaluate the name of path (%s), error: %s", pth, err)
+		}
+		if evaluatedName == "" || evaluatedName == "." || evaluatedName == ".." || strings.ContainsAny(evaluatedName, `/\`) {
+			return fmt.Errorf("Invalid evaluated name (%s) of path: %s", evaluatedName, pth)
+		}
+		evaluatedPth := filepath.Join(filepath.Dir(pth), evaluatedName)
+		if isExist, err := pathutil.IsPathExists(evaluatedPth); err != nil {
+			return err
+		} else if isExist {
+			return fmt.Errorf("Evaluated name of path (%s) already exists: %s", pth, evaluatedPth)
+		}
+		evalContext.logger().Debugf("-> Renaming %s to %s", pth, evaluatedName)
+		if err := os.Rename(pth, evaluatedPth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderedSeedModel ...
+type renderedSeedModel struct {
+	// PlantDerivedFiles : the files which were rendered from the plant's current
+	//  file (append, patch and merge file modes), by slash separated relative path
+	PlantDerivedFiles map[string]bool
+	// SeedRevision : the resolved revision of a remote seed (set by renderPlant)
+	SeedRevision string
+	// SeedContentHash : hash of the seed directory's content (set by renderPlant)
+	SeedContentHash string
+	// VarsHash : hash of the plant's resolved vars (set by renderPlant)
+	VarsHash string
+	// GardenHash : hash of the garden's partials and plants (set by renderPlant)
+	GardenHash string
+}
+
+// renderSeed ...
+//  copies the seed into the target dir and evaluates its templates
+//  and file modes; plantDirPth is the directory of the plant's
+//  current content, the file modes are applied on.
+//  The progress is logged into the logger.
+func renderSeed(gardenDirAbsPth, seedDirPth, targetDirPth, plantDirPth string, templateInventory GardenTemplateInventoryModel, gardenMap config.GardenMapModel, logger *log.Logger) (renderedSeedModel, error) {
+	if _, err := copyDirContent(seedDirPth, targetDirPth, false); err != nil {
+		return renderedSeedModel{}, fmt.Errorf("Failed to copy seed to temporary seed dir: %s", err)
+	}
+	if err := removeSeedOnlyFiles(targetDirPth); err != nil {
+		return renderedSeedModel{}, fmt.Errorf("Failed to cleanup temporary seed dir, error: %s", err)
 	}
 
-	log.Println("--> Checking seed: ", plantModel.Seed, "...")
-	seedDirFullPth, err := checkSeedDir(gardenDirAbsPth, plantModel.Seed)
+	seedConfig, err := config.LoadSeedConfig(seedDirPth)
 	if err != nil {
-		return fmt.Errorf("Failed to check seed directory: %s", err)
+		return renderedSeedModel{}, fmt.Errorf("Failed to load seed config, error: %s", err)
 	}
-	tmpSeedPth, err := pathutil.NormalizedOSTempDirPath("")
-	log.Debugln("    temp seed dir: ", tmpSeedPth)
+
+	logger.Println("--> Loading template partials ...")
+	partials, err := loadTemplatePartials(
+		filepath.Join(gardenDirAbsPth, partialsDirName),
+		filepath.Join(seedDirPth, config.SeedMetaDirName, partialsDirName))
 	if err != nil {
-		return fmt.Errorf("Failed to create a temporary directory for seed: %s", err)
+		return renderedSeedModel{}, fmt.Errorf("Failed to load template partials, error: %s", err)
+	}
+
+	logger.Println("--> Handling templates ...")
+	evalContext := templateEvaluationContextModel{
+		Inventory: templateInventory,
+		GardenMap: gardenMap,
+		Partials:  partials,
+		IsStrict:  gardenMap.IsStrictVars(),
+		Logger:    logger,
 	}
-	// only content of dir
-	output, err := cmdex.RunCommandAndReturnCombinedStdoutAndStderr("rsync",
-		"-avhP", filepath.Clean(seedDirFullPth)+"/", filepath.Clean(tmpSeedPth)+"/")
+	if len(seedConfig.TemplateDelimiters) == 2 {
+		evalContext.Delimiters = templateDelimitersModel{
+			Left:  seedConfig.TemplateDelimiters[0],
+			Right: seedConfig.TemplateDelimiters[1],
+		}
+	}
+
+	if err := evaluatePathTemplates(targetDirPth, evalContext); err != nil {
+		return renderedSeedModel{}, fmt.Errorf("Failed to evaluate the file names of temp seed dir (path:%s), error: %s", targetDirPth, err)
+	}
+	if err := replaceTemplateFilesInDir(targetDirPth, evalContext); err != nil {
+		return renderedSeedModel{}, fmt.Errorf("Failed to handle templates in temp seed dir (path:%s), error: %s", targetDirPth, err)
+	}
+
+	logger.Println("--> Applying file modes ...")
+	plantDerivedFiles, err := applyFileModesInDir(targetDirPth, plantDirPth, seedConfig, logger)
 	if err != nil {
-		log.Errorf("Failed to rsync seed to temporary seed dir: %s", err)
-		log.Errorf("Output was: %s", output)
-		return err
+		return renderedSeedModel{}, fmt.Errorf("Failed to apply file modes, error: %s", err)
+	}
+
+	logger.Println("--> Applying directories, symlinks and permissions ...")
+	if err := applySeedLayout(targetDirPth, seedConfig, evalContext); err != nil {
+		return renderedSeedModel{}, fmt.Errorf("Failed to apply the seed's layout, error: %s", err)
+	}
+
+	renderedSeed := renderedSeedModel{PlantDerivedFiles: map[string]bool{}}
+	for _, aPth := range plantDerivedFiles {
+		renderedSeed.PlantDerivedFiles[aPth] = true
+	}
+	return renderedSeed, nil
+}
+
+// growOptionsModel ...
+type growOptionsModel struct {
+	// IsDryRun : the plants are rendered and compared with
+	//  the plant dirs, but the plant dirs are not changed
+	IsDryRun bool
+	// ConflictPolicy : the policy for the locally edited files which
+	//  don't match any of the garden map's conflict_policies (default: fail)
+	ConflictPolicy string
+	// IsPrune : remove the files a previous grow wrote,
+	//  but the seed no longer produces
+	IsPrune bool
+	// Confirm : asks for confirmation before pruning,
+	//  nothing is asked if it's nil
+	Confirm confirmFunc
+	// IsForce : grow the plants which are up to date too
+	IsForce bool
+	// Jobs : the max number of plants grown at the same time (default: 1)
+	Jobs int
+	// IsKeepGoing : grow every plant, even if an other plant failed
+	//  (by default no more plants are started after a failure)
+	IsKeepGoing bool
+	// IsSnapshot : take a snapshot of the plants' garden managed
+	//  files before they're grown
+	IsSnapshot bool
+}
+
+// growRunModel ...
+//  the data which is the same for every plant of a single grow
+type growRunModel struct {
+	Options   growOptionsModel
+	Host      GardenTemplateHostModel
+	Timestamp time.Time
+	// Lock : the garden's lock, seeds which are not yet locked
+	//  are added to it during the grow
+	Lock config.GardenLockModel
+	// State : the garden's state, updated with the grown plants
+	State config.GardenStateModel
+	// Mutex : guards the Lock, the State and the seeds' cache dir,
+	//  if the plants are grown in parallel (optional)
+	Mutex *sync.Mutex
+}
+
+// lock ...
+func (growRun growRunModel) lock() {
+	if growRun.Mutex != nil {
+		growRun.Mutex.Lock()
+	}
+}
+
+// unlock ...
+func (growRun growRunModel) unlock() {
+	if growRun.Mutex != nil {
+		growRun.Mutex.Unlock()
 	}
+}
+
+// plantGrowResultModel ...
+type plantGrowResultModel struct {
+	PlantID   string
+	Conflicts []fileConflictModel
+	// IsSkipped : the plant was up to date, it was not grown
+	IsSkipped bool
+	// Duration : how long the plant's grow took
+	Duration time.Duration
+	// Err : the error the plant's grow failed with
+	Err error
+}
+
+const (
+	plantGrowStatusSucceeded  = "succeeded"
+	plantGrowStatusFailed     = "failed"
+	plantGrowStatusSkipped    = "skipped"
+	plantGrowStatusNotStarted = "not started"
+)
 
-	log.Println("--> Handling templates ...")
+// Status ...
+func (result plantGrowResultModel) Status() string {
+	if result.Err != nil {
+		return plantGrowStatusFailed
+	}
+	if result.IsSkipped {
+		return plantGrowStatusSkipped
+	}
+	return plantGrowStatusSucceeded
+}
+
+// createTemplateInventory ...
+//  the template inventory of a plant
+func createTemplateInventory(plantID string, gardenMap config.GardenMapModel, gardenDirAbsPth string, growRun growRunModel) (GardenTemplateInventoryModel, error) {
+	plantModel, isFound := gardenMap.Plants[plantID]
+	if !isFound {
+		return GardenTemplateInventoryModel{}, fmt.Errorf("Can't find Plant with ID: %s", plantID)
+	}
 	expandedPlantPath := plantModel.ExpandedPath(plantID)
 	absPlantPath, err := pathutil.AbsPath(expandedPlantPath)
 	if err != nil {
-		return fmt.Errorf("Failed to get Absolute path of plant (path:%s), error: %s", expandedPlantPath, err)
+		return GardenTemplateInventoryModel{}, fmt.Errorf("Failed to get Absolute path of plant (path:%s), error: %s", expandedPlantPath, err)
 	}
 	collectedPlantVars, err := gardenMap.CollectAllVarsForPlant(plantID)
 	if err != nil {
-		return fmt.Errorf("growPlant: failed to collect Vars for Plant (id: %s), error: %s", plantID, err)
+		return GardenTemplateInventoryModel{}, fmt.Errorf("Failed to collect Vars for Plant (id: %s), error: %s", plantID, err)
 	}
 	templateInventory := GardenTemplateInventoryModel{
-		TestBool:  true,
-		Vars:      collectedPlantVars,
-		PlantID:   plantID,
-		PlantPath: absPlantPath,
+		Vars:              collectedPlantVars,
+		PlantID:           plantID,
+		PlantPath:         absPlantPath,
+		PlantExpandedPath: expandedPlantPath,
+		Zones:             plantModel.Zones,
+		Seed:              plantModel.Seed,
+		GardenDir:         gardenDirAbsPth,
+		Host:              growRun.Host,
+		Timestamp:         growRun.Timestamp,
 	}
+	return templateInventory, nil
+}
+
+// preparedPlantModel ...
+//  the resolved inputs of a plant's render
+type preparedPlantModel struct {
+	ResolvedSeed      resolvedSeedModel
+	TemplateInventory GardenTemplateInventoryModel
+	// SeedContentHash : hash of the seed directory's content
+	SeedContentHash string
+	// VarsHash : hash of the plant's resolved vars
+	VarsHash string
+	// GardenHash : hash of the garden level inputs of the templates,
+	//  the garden's partials and every plant's ID, path, vars and zones
+	GardenHash string
+}
 
-	if err := replaceTemplateFilesInDir(tmpSeedPth, templateInventory); err != nil {
-		return fmt.Errorf("Failed to handle templates in temp seed dir (path:%s), error: %s", tmpSeedPth, err)
+// preparePlant ...
+//  resolves the plant's seed and template inventory
+func preparePlant(plantID string, gardenMap config.GardenMapModel, gardenDirAbsPth string, growRun growRunModel, logger *log.Logger) (preparedPlantModel, error) {
+	plantModel, isFound := gardenMap.Plants[plantID]
+	if !isFound {
+		return preparedPlantModel{}, fmt.Errorf("Can't find Plant with ID: %s", plantID)
 	}
 
-	log.Println("--> Moving plant to it's final place in the garden ...")
-	log.Println("    Plant's final place: ", absPlantPath)
-	// only content of dir
-	output, err = cmdex.RunCommandAndReturnCombinedStdoutAndStderr("rsync",
-		"-avhP", filepath.Clean(tmpSeedPth)+"/", filepath.Clean(absPlantPath)+"/")
+	logger.Println("--> Checking seed: ", plantModel.Seed, "...")
+	// the seeds' cache dir and the lock are shared by the plants
+	growRun.lock()
+	resolvedSeed, err := resolveLockedSeed(gardenDirAbsPth, plantModel, growRun.Lock, false, logger)
+	growRun.unlock()
 	if err != nil {
-		log.Errorf("Failed to rsync temporary seed dir to it's final place: %s", err)
-		log.Errorf("Output was: %s", output)
-		return err
+		return preparedPlantModel{}, fmt.Errorf("Failed to check seed directory: %s", err)
 	}
 
-	log.Println("--> Cleaning up ...")
-	if err := os.RemoveAll(tmpSeedPth); err != nil {
-		return fmt.Errorf("Failed to cleanup: %s", err)
+	templateInventory, err := createTemplateInventory(plantID, gardenMap, gardenDirAbsPth, growRun)
+	if err != nil {
+		return preparedPlantModel{}, err
 	}
-	log.Debugln("    [OK] Removed temp seed dir:", tmpSeedPth)
 
-	log.Println("🌴")
-	log.Println("-> Plant grown!")
-	return nil
+	preparedPlant := preparedPlantModel{ResolvedSeed: resolvedSeed, TemplateInventory: templateInventory}
+	if preparedPlant.SeedContentHash, err = seedContentHash(resolvedSeed.Dir); err != nil {
+		return preparedPlantModel{}, fmt.Errorf("Failed to calculate the seed's content hash, error: %s", err)
+	}
+	if preparedPlant.VarsHash, err = varsHash(templateInventory.Vars); err != nil {
+		return preparedPlantModel{}, fmt.Errorf("Failed to calculate the vars' hash, error: %s", err)
+	}
+	if preparedPlant.GardenHash, err = gardenHash(gardenDirAbsPth, gardenMap); err != nil {
+		return preparedPlantModel{}, fmt.Errorf("Failed to calculate the garden's hash, error: %s", err)
+	}
+	return preparedPlant, nil
+}
+
+// renderPreparedPlant ...
+//  renders the prepared plant's seed into the target dir
+func renderPreparedPlant(preparedPlant preparedPlantModel, gardenMap config.GardenMapModel, gardenDirAbsPth, targetDirPth string, logger *log.Logger) (renderedSeedModel, error) {
+	templateInventory := preparedPlant.TemplateInventory
+	renderedSeed, err := renderSeed(gardenDirAbsPth, preparedPlant.ResolvedSeed.Dir, targetDirPth, templateInventory.PlantPath, templateInventory, gardenMap, logger)
+	if err != nil {
+		return renderedSeedModel{}, err
+	}
+	renderedSeed.SeedRevision = preparedPlant.ResolvedSeed.Revision
+	renderedSeed.SeedContentHash = preparedPlant.SeedContentHash
+	renderedSeed.VarsHash = preparedPlant.VarsHash
+	renderedSeed.GardenHash = preparedPlant.GardenHash
+	return renderedSeed, nil
 }
 
-func growPlants(gardenDirAbsPth string, gardenMap config.GardenMapModel, plantsToGrowIDs []string) error {
-	for _, plantID := range plantsToGrowIDs {
-		if err := growPlant(plantID, gardenMap, gardenDirAbsPth); err != nil {
+// renderPlant ...
+//  resolves the plant's seed and renders it into the target dir,
+//  returns the absolute path of the plant
+func renderPlant(plantID string, gardenMap config.GardenMapModel, gardenDirAbsPth string, growRun growRunModel, targetDirPth string) (string, renderedSeedModel, error) {
+	preparedPlant, err := preparePlant(plantID, gardenMap, gardenDirAbsPth, growRun, log.StandardLogger())
+	if err != nil {
+		return "", renderedSeedModel{}, err
+	}
+	renderedSeed, err := renderPreparedPlant(preparedPlant, gardenMap, gardenDirAbsPth, targetDirPth, log.StandardLogger())
+	if err != nil {
+		return "", renderedSeedModel{}, err
+	}
+	return preparedPlant.TemplateInventory.PlantPath, renderedSeed, nil
+}
+
+// isPlantUpToDate ...
+//  whether the plant's last grow had the same seed (reference and content
+//  hash), vars, garden partials and plants, plant path and garden version,
+//  and the files it wrote were not changed since then
+func isPlantUpToDate(preparedPlant preparedPlantModel, plantState config.PlantStateModel) (bool, error) {
+	templateInventory := preparedPlant.TemplateInventory
+	if !plantState.IsGrown() ||
+		plantState.Seed != templateInventory.Seed ||
+		plantState.SeedContentHash != preparedPlant.SeedContentHash ||
+		plantState.VarsHash != preparedPlant.VarsHash ||
+		plantState.GardenHash != preparedPlant.GardenHash ||
+		plantState.PlantPath != templateInventory.PlantPath ||
+		plantState.GardenVersion != gardenVersion {
+		return false, nil
+	}
+
+	recordedPths := []string{}
+	for aPth := range plantState.Files {
+		recordedPths = append(recordedPths, aPth)
+	}
+	recordedFiles, err := classifyRecordedFiles(templateInventory.PlantPath, plantState, recordedPths)
+	if err != nil {
+		return false, err
+	}
+	return len(recordedFiles.Edited) == 0 && len(recordedFiles.Missing) == 0, nil
+}
+
+// varsHash ...
+//  SHA256 hash of the vars, independent of the order of the keys
+func varsHash(vars map[string]string) (string, error) {
+	// the keys of a map are sorted in the JSON
+	varsBytes, err := json.Marshal(vars)
+	if err != nil {
+		return "", err
+	}
+	return stringSHA256(string(varsBytes)), nil
+}
+
+// gardenHash ...
+//  SHA256 hash of the inputs of the templates which are shared by the plants:
+//  the content of the garden's partials dir and every plant's ID, path,
+//  vars (the plant lookup template functions) and zones
+func gardenHash(gardenDirAbsPth string, gardenMap config.GardenMapModel) (string, error) {
+	type hashedPlantModel struct {
+		GardenTemplatePlantModel
+		Zones []string
+	}
+
+	partialsHash := ""
+	partialsDirPth := filepath.Join(gardenDirAbsPth, partialsDirName)
+	if isExist, err := pathutil.IsDirExists(partialsDirPth); err != nil {
+		return "", err
+	} else if isExist {
+		if partialsHash, err = seedContentHash(partialsDirPth); err != nil {
+			return "", err
+		}
+	}
+
+	plantIDs := []string{}
+	for aPlantID := range gardenMap.Plants {
+		plantIDs = append(plantIDs, aPlantID)
+	}
+	sort.Strings(plantIDs)
+	plants := []hashedPlantModel{}
+	for _, aPlantID := range plantIDs {
+		templatePlant, err := createTemplatePlantModel(gardenMap, aPlantID)
+		if err != nil {
+			return "", err
+		}
+		plants = append(plants, hashedPlantModel{GardenTemplatePlantModel: templatePlant, Zones: gardenMap.Plants[aPlantID].Zones})
+	}
+	plantsBytes, err := json.Marshal(plants)
+	if err != nil {
+		return "", err
+	}
+	return stringSHA256(partialsHash + "\n" + string(plantsBytes)), nil
+}
+
+func growPlant(plantID string, gardenMap config.GardenMapModel, gardenDirAbsPth string, growRun growRunModel, logger *log.Logger) (plantGrowResultModel, error) {
+	result := plantGrowResultModel{PlantID: plantID, Conflicts: []fileConflictModel{}}
+	fmt.Fprintln(logger.Out)
+	logger.Println(colorstring.Yellow("==> growing plant:"), colorstring.Green(plantID))
+	logger.Println("🌱")
+
+	preparedPlant, err := preparePlant(plantID, gardenMap, gardenDirAbsPth, growRun, logger)
+	if err != nil {
+		return result, err
+	}
+	growRun.lock()
+	plantState := growRun.State.PlantState(plantID)
+	growRun.unlock()
+	if !growRun.Options.IsForce {
+		isUpToDate, err := isPlantUpToDate(preparedPlant, plantState)
+		if err != nil {
+			return result, fmt.Errorf("Failed to check whether the plant is up to date, error: %s", err)
+		}
+		if isUpToDate {
+			logger.Printf("-> Plant is up to date, skipped (grow it anyway with --%s)", ForceKey)
+			result.IsSkipped = true
+			return result, nil
+		}
+	}
+
+	tmpSeedPth, err := pathutil.NormalizedOSTempDirPath("")
+	if err != nil {
+		return result, fmt.Errorf("Failed to create a temporary directory for seed: %s", err)
+	}
+	logger.Debugln("    temp seed dir: ", tmpSeedPth)
+	// the temp dirs are removed even if the grow fails
+	defer func() {
+		if err := os.RemoveAll(tmpSeedPth); err != nil {
+			logger.Warnf("Failed to remove the temporary seed dir (path:%s), error: %s", tmpSeedPth, err)
+			return
+		}
+		logger.Debugln("    [OK] Removed temp seed dir:", tmpSeedPth)
+	}()
+
+	// the plant is rendered and staged in the temp seed dir first,
+	//  the plant dir is only changed if the whole seed could be rendered
+	absPlantPath := preparedPlant.TemplateInventory.PlantPath
+	renderedSeed, err := renderPreparedPlant(preparedPlant, gardenMap, gardenDirAbsPth, tmpSeedPth, logger)
+	if err != nil {
+		return result, err
+	}
+
+	isDryRun := growRun.Options.IsDryRun
+	logger.Println("--> Checking local changes ...")
+	conflicts, err := detectConflicts(tmpSeedPth, absPlantPath, plantState, renderedSeed, gardenMap, growRun.Options.ConflictPolicy)
+	if err != nil {
+		return result, fmt.Errorf("Failed to check local changes, error: %s", err)
+	}
+	result.Conflicts = conflicts
+	mergeBasesDirPth := config.PlantMergeBasesDirPath(gardenDirAbsPth, plantID)
+
+	// every path of the plant dir and its merge bases is saved before it's
+	//  changed, and restored if the grow fails
+	var transaction *plantTransactionModel
+	if !isDryRun {
+		if transaction, err = newPlantTransaction(); err != nil {
+			return result, fmt.Errorf("Failed to create a temporary directory for the plant's backup: %s", err)
+		}
+		defer func() {
+			if err := transaction.cleanup(); err != nil {
+				logger.Warnf("Failed to remove the plant's temporary backup dir, error: %s", err)
+			}
+		}()
+	}
+
+	isSnapshotTaken := false
+	swapErr := func() error {
+		if growRun.Options.IsSnapshot && !isDryRun && plantState.IsGrown() {
+			logger.Println("--> Taking a snapshot of the plant ...")
+			snapshot, err := takePlantSnapshot(gardenDirAbsPth, plantID, absPlantPath, plantState, growRun.Timestamp, transaction)
+			if err != nil {
+				return fmt.Errorf("Failed to take a snapshot of the plant, error: %s", err)
+			}
+			logger.Printf("    snapshot: %s (%d file(s))", snapshot.ID, len(snapshot.Files))
+			isSnapshotTaken = true
+		}
+
+		if err := resolveConflicts(conflicts, tmpSeedPth, absPlantPath, mergeBasesDirPth, growRun.Timestamp, isDryRun, transaction, logger); err != nil {
 			return err
 		}
+
+		if isDryRun {
+			logger.Println("--> Comparing with the plant (dry run) ...")
+		} else {
+			logger.Println("--> Moving plant to it's final place in the garden ...")
+		}
+		logger.Println("    Plant's final place: ", absPlantPath)
+		if err := transaction.saveCopyTargets(tmpSeedPth, absPlantPath); err != nil {
+			return fmt.Errorf("Failed to back up the plant's files, error: %s", err)
+		}
+		copyResult, err := copyDirContent(tmpSeedPth, absPlantPath, isDryRun)
+		if err != nil {
+			return fmt.Errorf("Failed to copy temporary seed dir to it's final place: %s", err)
+		}
+		logCopyResult(copyResult, absPlantPath, isDryRun, logger)
+
+		// the files skipped because of a conflict are not in the temp seed dir,
+		//  their recorded hashes are kept
+		writtenFileHashes, err := collectFileHashes(tmpSeedPth)
+		if err != nil {
+			return fmt.Errorf("Failed to record the written files, error: %s", err)
+		}
+		pruneCandidatePths := pruneCandidatePaths(plantState, writtenFileHashes, conflicts)
+		if !isDryRun {
+			for aPth, aHash := range writtenFileHashes {
+				plantState.Files[aPth] = aHash
+			}
+			// a merged file keeps the local changes, the hash of its rendered
+			//  version is recorded, so it's merged again on the next grow
+			for _, aConflict := range conflicts {
+				if aConflict.IsMerged {
+					plantState.Files[aConflict.Path] = stringSHA256(aConflict.RenderedContent)
+				}
+			}
+
+			if err := saveMergeBases(mergeBasesDirPth, tmpSeedPth, conflicts, transaction); err != nil {
+				return fmt.Errorf("Failed to save the merge bases, error: %s", err)
+			}
+		}
+
+		if growRun.Options.IsPrune {
+			if err := prunePlant(plantID, absPlantPath, mergeBasesDirPth, plantState, pruneCandidatePths, growRun.Options, transaction, logger); err != nil {
+				return fmt.Errorf("Failed to prune plant, error: %s", err)
+			}
+		}
+		return nil
+	}()
+	if swapErr != nil {
+		if transaction.isEmpty() {
+			return result, swapErr
+		}
+		logger.Warnln("--> Rolling back the plant's changes ...")
+		if err := transaction.rollback(); err != nil {
+			return result, fmt.Errorf("%s - and failed to roll back the plant's changes, error: %s", swapErr, err)
+		}
+		logger.Warnln("    the plant's previous content was restored")
+		return result, swapErr
 	}
-	return nil
+
+	if isDryRun {
+		logger.Println("-> Plant checked, nothing was changed (dry run)")
+		return result, nil
+	}
+
+	if isSnapshotTaken {
+		if err := removeExpiredSnapshots(gardenDirAbsPth, plantID, gardenMap.SnapshotsConfig(), growRun.Timestamp, logger); err != nil {
+			logger.Warnf("Failed to remove the expired snapshots of the plant, error: %s", err)
+		}
+	}
+
+	// the state is only updated if the plant was grown
+	plantState.GrownAt = growRun.Timestamp
+	plantState.Seed = gardenMap.Plants[plantID].Seed
+	plantState.PlantPath = absPlantPath
+	plantState.SeedRevision = renderedSeed.SeedRevision
+	plantState.SeedContentHash = renderedSeed.SeedContentHash
+	plantState.VarsHash = renderedSeed.VarsHash
+	plantState.GardenHash = renderedSeed.GardenHash
+	plantState.GardenVersion = gardenVersion
+	growRun.lock()
+	growRun.State.Plants[plantID] = plantState
+	growRun.unlock()
+
+	logger.Println("🌴")
+	logger.Println("-> Plant grown!")
+	return result, nil
+}
+
+// growSummaryTable ...
+//  a row for every plant (in the order of plantIDs): its status,
+//  the duration of its grow and the first line of its error.
+//  The plants without a result were not started.
+func growSummaryTable(plantIDs []string, results []plantGrowResultModel) []string {
+	resultByPlantID := map[string]plantGrowResultModel{}
+	for _, aResult := range results {
+		resultByPlantID[aResult.PlantID] = aResult
+	}
+	plantIDWidth := len("PLANT")
+	for _, aPlantID := range plantIDs {
+		if len(aPlantID) > plantIDWidth {
+			plantIDWidth = len(aPlantID)
+		}
+	}
+
+	rowFormat := fmt.Sprintf("%%-%ds  %%-11s  %%-8s  %%s", plantIDWidth)
+	rows := []string{strings.TrimSpace(fmt.Sprintf(rowFormat, "PLANT", "STATUS", "DURATION", "ERROR"))}
+	for _, aPlantID := range plantIDs {
+		result, isStarted := resultByPlantID[aPlantID]
+		if !isStarted {
+			rows = append(rows, strings.TrimSpace(fmt.Sprintf(rowFormat, aPlantID, plantGrowStatusNotStarted, "-", "")))
+			continue
+		}
+		errorLine := ""
+		if result.Err != nil {
+			errorLine = strings.SplitN(strings.TrimSpace(result.Err.Error()), "\n", 2)[0]
+		}
+		duration := result.Duration.Round(10 * time.Millisecond).String()
+		rows = append(rows, strings.TrimSpace(fmt.Sprintf(rowFormat, aPlantID, result.Status(), duration, errorLine)))
+	}
+	return rows
+}
+
+// logGrowSummary ...
+func logGrowSummary(plantIDs []string, results []plantGrowResultModel) {
+	statusCounts := map[string]int{}
+	conflictCount := 0
+	for _, aResult := range results {
+		statusCounts[aResult.Status()]++
+		conflictCount += len(aResult.Conflicts)
+	}
+	statusCounts[plantGrowStatusNotStarted] = len(plantIDs) - len(results)
+
+	if conflictCount > 0 {
+		fmt.Println()
+		log.Warnf("%d locally edited file(s) were changed by the seeds too:", conflictCount)
+		for _, aResult := range results {
+			for _, aConflict := range aResult.Conflicts {
+				log.Warnf(" - %s: %s", aResult.PlantID, aConflict)
+			}
+		}
+	}
+
+	fmt.Println()
+	log.Infoln(colorstring.Yellow("==> Grow summary"))
+	for _, aRow := range growSummaryTable(plantIDs, results) {
+		log.Infof("    %s", aRow)
+	}
+	summary := fmt.Sprintf("%d succeeded, %d failed, %d skipped (up to date)",
+		statusCounts[plantGrowStatusSucceeded], statusCounts[plantGrowStatusFailed], statusCounts[plantGrowStatusSkipped])
+	if statusCounts[plantGrowStatusNotStarted] > 0 {
+		summary += fmt.Sprintf(", %d not started (grow every plant with --%s)", statusCounts[plantGrowStatusNotStarted], KeepGoingKey)
+	}
+	if statusCounts[plantGrowStatusFailed] > 0 {
+		log.Errorln(summary)
+		return
+	}
+	log.Infoln(summary)
+}
+
+// growPlants ...
+func growPlants(gardenDirAbsPth string, gardenMap config.GardenMapModel, plantsToGrowIDs []string, options growOptionsModel) error {
+	gardenLock, err := config.LoadGardenLock(gardenDirAbsPth)
+	if err != nil {
+		return fmt.Errorf("Failed to load garden lock, error: %s", err)
+	}
+	warnIfGardenLockIsStale(gardenMap, gardenLock)
+	origGardenLock := gardenLock.Copy()
+
+	gardenState, err := config.LoadGardenState(gardenDirAbsPth)
+	if err != nil {
+		return fmt.Errorf("Failed to load garden state, error: %s", err)
+	}
+
+	if options.ConflictPolicy == "" {
+		options.ConflictPolicy = config.ConflictPolicyFail
+	}
+	growRun := growRunModel{
+		Options:   options,
+		Host:      createTemplateHostModel(),
+		Timestamp: time.Now(),
+		Lock:      gardenLock,
+		State:     gardenState,
+		Mutex:     &sync.Mutex{},
+	}
+
+	isParallel := options.Jobs > 1 && len(plantsToGrowIDs) > 1
+	if isParallel {
+		log.Infof("Growing %d plant(s), %d at a time", len(plantsToGrowIDs), options.Jobs)
+	}
+	// the logs of the plants grown in parallel are printed one at a time
+	var outputMutex sync.Mutex
+	growFn := func(plantID string) (plantGrowResultModel, error) {
+		plantLog := newPlantLog(isParallel)
+		plantGrowRun := growRun
+		if isParallel {
+			log.Infof("==> started growing plant: %s", plantID)
+			if options.Confirm != nil {
+				// the plant's log so far (e.g. the files to prune) is printed before the question
+				plantGrowRun.Options.Confirm = func(question string) (bool, error) {
+					outputMutex.Lock()
+					defer outputMutex.Unlock()
+					plantLog.flush()
+					return options.Confirm(question)
+				}
+			}
+		}
+
+		startTime := time.Now()
+		result, err := growPlant(plantID, gardenMap, gardenDirAbsPth, plantGrowRun, plantLog.Logger)
+		result.Duration = time.Since(startTime)
+		result.Err = err
+		if err != nil {
+			plantLog.Logger.Errorf("-> Failed to grow plant: %s", err)
+			err = fmt.Errorf("Failed to grow plant (%s), error: %s", plantID, err)
+		}
+		outputMutex.Lock()
+		plantLog.flush()
+		outputMutex.Unlock()
+		return result, err
+	}
+	results, growErr := runPlantGrowJobs(plantsToGrowIDs, plantGrowDependencies(gardenMap, plantsToGrowIDs), options.Jobs, options.IsKeepGoing, growFn)
+	logGrowSummary(plantsToGrowIDs, results)
+	if options.IsKeepGoing {
+		failedPlantIDs := []string{}
+		for _, aResult := range results {
+			if aResult.Err != nil {
+				failedPlantIDs = append(failedPlantIDs, aResult.PlantID)
+			}
+		}
+		if len(failedPlantIDs) > 0 {
+			growErr = fmt.Errorf("%d of %d plant(s) failed to grow: %s", len(failedPlantIDs), len(plantsToGrowIDs), strings.Join(failedPlantIDs, ", "))
+		}
+	}
+
+	if options.IsDryRun {
+		// a dry run doesn't change the lock or the state
+		return growErr
+	}
+	// seeds locked during the grow
+	if !reflect.DeepEqual(origGardenLock, gardenLock) {
+		if err := config.SaveGardenLock(gardenDirAbsPth, gardenLock); err != nil {
+			log.Errorf("Failed to save garden lock, error: %s", err)
+		}
+	}
+	// the plants grown before an error are recorded too
+	if err := config.SaveGardenState(gardenDirAbsPth, gardenState); err != nil {
+		log.Errorf("Failed to save garden state, error: %s", err)
+	}
+	return growErr
 }
 
 func grow(c *cli.Context) {
@@ -172,7 +837,28 @@ func grow(c *cli.Context) {
 	if len(plantsToGrowIDs) < 1 {
 		log.Fatalln("No plants to grow!")
 	}
-	if err := growPlants(gardenDirAbsPth, gardenMap, plantsToGrowIDs); err != nil {
+	// the plants which can't be grown at the same time are grown in this order
+	sort.Strings(plantsToGrowIDs)
+	options := growOptionsModel{
+		IsDryRun:       c.Bool(DryRunKey),
+		ConflictPolicy: c.String(OnConflictKey),
+		IsPrune:        c.Bool(PruneKey),
+		IsForce:        c.Bool(ForceKey),
+		Jobs:           c.Int(JobsKey),
+		IsKeepGoing:    c.Bool(KeepGoingKey),
+		IsSnapshot:     c.Bool(SnapshotKey),
+	}
+	if options.Jobs < 1 {
+		log.Fatalf("Invalid --%s (%d), at least 1 plant has to be grown at a time", JobsKey, options.Jobs)
+	}
+	if !c.Bool(YesKey) {
+		options.Confirm = askForConfirmation
+	}
+	if !config.IsValidConflictPolicy(options.ConflictPolicy) {
+		log.Fatalf("Invalid --%s policy (%s), options: %s, %s, %s, %s, %s", OnConflictKey, options.ConflictPolicy,
+			config.ConflictPolicyFail, config.ConflictPolicySkip, config.ConflictPolicyBackup, config.ConflictPolicyForce, config.ConflictPolicyMerge)
+	}
+	if err := growPlants(gardenDirAbsPth, gardenMap, plantsToGrowIDs, options); err != nil {
 		log.Fatalf("Failed to grow plants: %s", err)
 	}
 }
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// plantLogModel ...
+//  the log of a plant's grow. If the plants are grown in parallel
+//  it's collected in a buffer, and printed in one group, so the
+//  logs of the plants are not mixed.
+type plantLogModel struct {
+	Logger *log.Logger
+	buffer *bytes.Buffer
+}
+
+// newPlantLog ...
+//  a buffered log with the standard logger's settings,
+//  or the standard logger itself if isBuffered is false
+func newPlantLog(isBuffered bool) plantLogModel {
+	stdLogger := log.StandardLogger()
+	if !isBuffered {
+		return plantLogModel{Logger: stdLogger}
+	}
+	buffer := &bytes.Buffer{}
+	return plantLogModel{
+		Logger: &log.Logger{
+			Out:       buffer,
+			Formatter: stdLogger.Formatter,
+			Hooks:     stdLogger.Hooks,
+			Level:     stdLogger.Level,
+		},
+		buffer: buffer,
+	}
+}
+
+// flush ...
+//  prints the buffered log into the standard logger's output
+func (plantLog plantLogModel) flush() {
+	if plantLog.buffer == nil {
+		return
+	}
+	if _, err := plantLog.buffer.WriteTo(log.StandardLogger().Out); err != nil {
+		log.Warnf("Failed to print the plant's log, error: %s", err)
+	}
+}
+
+// isSameOrSubDir ...
+func isSameOrSubDir(dirPth, subDirPth string) bool {
+	return subDirPth == dirPth || strings.HasPrefix(subDirPth, dirPth+string(os.PathSeparator))
+}
+
+// plantGrowDependencies ...
+//  the plants which have to be grown before the plant, by plant ID.
+//  These plants can't be grown at the same time, so those are grown
+//  one after the other, in the order of plantIDs:
+//  * plants which are grown into the same dir, or into a dir inside
+//    an other plant's dir
+//  * plants with seeds which share a cache dir: the same git seed
+//    repository (with any ref, the repository is fetched and checked out
+//    in its cache dir), or archive seeds with the same checksum
+func plantGrowDependencies(gardenMap config.GardenMapModel, plantIDs []string) map[string][]string {
+	plantPaths := map[string]string{}
+	seedCacheKeys := map[string]string{}
+	for _, aPlantID := range plantIDs {
+		plantModel := gardenMap.Plants[aPlantID]
+		// the errors are reported by the plant's grow
+		if absPlantPath, err := pathutil.AbsPath(plantModel.ExpandedPath(aPlantID)); err == nil {
+			plantPaths[aPlantID] = filepath.Clean(absPlantPath)
+		}
+		if seedRef, err := config.ParseSeedRef(plantModel.Seed); err == nil {
+			switch seedRef.Source {
+			case config.SeedSourceGit:
+				seedCacheKeys[aPlantID] = "git:" + seedRef.URL
+			case config.SeedSourceArchive:
+				seedCacheKeys[aPlantID] = "archive:" + strings.ToLower(plantModel.SeedSHA256)
+			}
+		}
+	}
+
+	isDependent := func(plantID, otherPlantID string) bool {
+		plantPath, isPathFound := plantPaths[plantID]
+		otherPlantPath, isOtherPathFound := plantPaths[otherPlantID]
+		if isPathFound && isOtherPathFound && (isSameOrSubDir(plantPath, otherPlantPath) || isSameOrSubDir(otherPlantPath, plantPath)) {
+			return true
+		}
+		seedCacheKey, isRemoteSeed := seedCacheKeys[plantID]
+		return isRemoteSeed && seedCacheKey == seedCacheKeys[otherPlantID]
+	}
+
+	dependencies := map[string][]string{}
+	for idx, aPlantID := range plantIDs {
+		dependencies[aPlantID] = []string{}
+		for _, anEarlierPlantID := range plantIDs[:idx] {
+			if isDependent(aPlantID, anEarlierPlantID) {
+				dependencies[aPlantID] = append(dependencies[aPlantID], anEarlierPlantID)
+			}
+		}
+	}
+	return dependencies
+}
+
+// plantJobResultModel ...
+type plantJobResultModel struct {
+	Index  int
+	Result plantGrowResultModel
+	Err    error
+}
+
+// runPlantGrowJobs ...
+//  calls growFn for the plants, at most jobs at the same time; a plant is
+//  started when the plants it depends on are done (even if those failed).
+//  After an error no more plants are started (unless isKeepGoing is true),
+//  the started ones are waited for.
+//  Returns the results of the started plants (in the order of plantIDs),
+//  and the first error.
+func runPlantGrowJobs(plantIDs []string, dependencies map[string][]string, jobs int, isKeepGoing bool, growFn func(plantID string) (plantGrowResultModel, error)) ([]plantGrowResultModel, error) {
+	if jobs < 1 {
+		jobs = 1
+	}
+	isStarted := map[string]bool{}
+	isDone := map[string]bool{}
+	isReady := func(plantID string) bool {
+		for _, aDependency := range dependencies[plantID] {
+			if !isDone[aDependency] {
+				return false
+			}
+		}
+		return true
+	}
+
+	jobResults := make(chan plantJobResultModel)
+	resultByIndex := map[int]plantGrowResultModel{}
+	runningCount := 0
+	var firstErr error
+	for {
+		for idx, aPlantID := range plantIDs {
+			if (firstErr != nil && !isKeepGoing) || runningCount >= jobs {
+				break
+			}
+			if isStarted[aPlantID] || !isReady(aPlantID) {
+				continue
+			}
+			isStarted[aPlantID] = true
+			runningCount++
+			go func(idx int, plantID string) {
+				result, err := growFn(plantID)
+				jobResults <- plantJobResultModel{Index: idx, Result: result, Err: err}
+			}(idx, aPlantID)
+		}
+		if runningCount == 0 {
+			break
+		}
+
+		jobResult := <-jobResults
+		runningCount--
+		isDone[plantIDs[jobResult.Index]] = true
+		resultByIndex[jobResult.Index] = jobResult.Result
+		if jobResult.Err != nil && firstErr == nil {
+			firstErr = jobResult.Err
+		}
+	}
+
+	results := []plantGrowResultModel{}
+	for idx := range plantIDs {
+		if result, isFound := resultByIndex[idx]; isFound {
+			results = append(results, result)
+		}
+	}
+	return results, firstErr
+}
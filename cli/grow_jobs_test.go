@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_plantGrowDependencies(t *testing.T) {
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app":       config.PlantModel{Path: "/plants/app", Seed: "app"},
+			"app-docs":  config.PlantModel{Path: "/plants/app/docs", Seed: "docs"},
+			"app-2":     config.PlantModel{Path: "/plants/app-2", Seed: "app"},
+			"same-dir":  config.PlantModel{Path: "/plants/app-2", Seed: "other"},
+			"git-v1":    config.PlantModel{Path: "/plants/git-v1", Seed: "git+https://example.com/seeds.git@v1"},
+			"git-v2":    config.PlantModel{Path: "/plants/git-v2", Seed: "git+https://example.com/seeds.git@v2"},
+			"git-v2-id": config.PlantModel{Path: "/plants/git-v2-id", Seed: "git+https://example.com/seeds.git@v2"},
+			"git-other": config.PlantModel{Path: "/plants/git-other", Seed: "git+https://example.com/other.git"},
+			"zip-a":     config.PlantModel{Path: "/plants/zip-a", Seed: "https://example.com/a.zip", SeedSHA256: "ABC"},
+			"zip-a-2":   config.PlantModel{Path: "/plants/zip-a-2", Seed: "https://example.com/a.zip//sub", SeedSHA256: "abc"},
+			"zip-b":     config.PlantModel{Path: "/plants/zip-b", Seed: "https://example.com/b.zip", SeedSHA256: "def"},
+		},
+	}
+	dependencies := plantGrowDependencies(gardenMap, []string{"app-docs", "app", "app-2", "same-dir", "git-v1", "git-v2", "git-v2-id", "git-other", "zip-a", "zip-a-2", "zip-b"})
+	require.Equal(t, map[string][]string{
+		"app-docs":  []string{},
+		"app":       []string{"app-docs"},
+		"app-2":     []string{},
+		"same-dir":  []string{"app-2"},
+		"git-v1":    []string{},
+		"git-v2":    []string{"git-v1"},
+		"git-v2-id": []string{"git-v1", "git-v2"},
+		"git-other": []string{},
+		"zip-a":     []string{},
+		"zip-a-2":   []string{"zip-a"},
+		"zip-b":     []string{},
+	}, dependencies)
+}
+
+func Test_runPlantGrowJobs(t *testing.T) {
+	var mutex sync.Mutex
+	runningCount, maxRunningCount := 0, 0
+	finished := []string{}
+	growFn := func(plantID string) (plantGrowResultModel, error) {
+		mutex.Lock()
+		runningCount++
+		if runningCount > maxRunningCount {
+			maxRunningCount = runningCount
+		}
+		mutex.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mutex.Lock()
+		runningCount--
+		finished = append(finished, plantID)
+		mutex.Unlock()
+		if plantID == "failing" {
+			return plantGrowResultModel{PlantID: plantID}, errors.New("failed")
+		}
+		return plantGrowResultModel{PlantID: plantID}, nil
+	}
+
+	t.Log("At most jobs plants at a time, the dependencies first")
+	plantIDs := []string{"a", "b", "c", "d", "e"}
+	results, err := runPlantGrowJobs(plantIDs, map[string][]string{"e": []string{"a"}}, 2, false, growFn)
+	require.NoError(t, err)
+	require.Equal(t, 2, maxRunningCount)
+	require.Equal(t, 5, len(results))
+	for idx, aResult := range results {
+		require.Equal(t, plantIDs[idx], aResult.PlantID)
+	}
+	finishedIdx := map[string]int{}
+	for idx, aPlantID := range finished {
+		finishedIdx[aPlantID] = idx
+	}
+	require.True(t, finishedIdx["a"] < finishedIdx["e"])
+
+	t.Log("One at a time")
+	maxRunningCount = 0
+	finished = []string{}
+	_, err = runPlantGrowJobs(plantIDs, map[string][]string{}, 1, false, growFn)
+	require.NoError(t, err)
+	require.Equal(t, 1, maxRunningCount)
+	require.Equal(t, plantIDs, finished)
+
+	t.Log("No more plants are started after an error")
+	finished = []string{}
+	results, err = runPlantGrowJobs([]string{"failing", "b", "c"}, map[string][]string{}, 1, false, growFn)
+	require.EqualError(t, err, "failed")
+	require.Equal(t, 1, len(results))
+	require.Equal(t, []string{"failing"}, finished)
+
+	t.Log("Keep going - every plant is started, the dependents of the failed plant too")
+	finished = []string{}
+	results, err = runPlantGrowJobs([]string{"failing", "b", "c"}, map[string][]string{"c": []string{"failing"}}, 2, true, growFn)
+	require.EqualError(t, err, "failed")
+	require.Equal(t, 3, len(results))
+	require.Equal(t, 3, len(finished))
+}
+
+func Test_growPlants_jobs(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "app", "README.md.template"), "# {{ .PlantID }}\n", 0644)
+
+	gardenMap := config.GardenMapModel{Plants: map[string]config.PlantModel{}}
+	plantIDs := []string{}
+	for idx := 1; idx <= 6; idx++ {
+		plantID := fmt.Sprintf("app-%d", idx)
+		plantIDs = append(plantIDs, plantID)
+		gardenMap.Plants[plantID] = config.PlantModel{Path: filepath.Join(tmpDir, "plants", plantID), Seed: "app"}
+	}
+	// grown into the first plant's dir
+	plantIDs = append(plantIDs, "nested")
+	gardenMap.Plants["nested"] = config.PlantModel{Path: filepath.Join(tmpDir, "plants", "app-1", "nested"), Seed: "app"}
+
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, plantIDs, growOptionsModel{Jobs: 3}))
+	for _, aPlantID := range plantIDs {
+		testFileContent(t, filepath.Join(gardenMap.Plants[aPlantID].Path, "README.md"), "# "+aPlantID+"\n")
+	}
+	gardenState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+	require.Equal(t, 7, len(gardenState.Plants))
+	require.Equal(t, 1, len(gardenState.PlantState("nested").Files))
+}
+
+func Test_growPlants_jobs_gitRefs(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	bareDirPth, workDirPth := createTestSeedRepository(t, tmpDir)
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(workDirPth, "seeds", "apples", "file.txt"), "v2\n"))
+	gitCommitForTest(t, workDirPth, "v2")
+	_, err = runGitCommand(workDirPth, "push", "--quiet", "origin", "master")
+	require.NoError(t, err)
+
+	t.Log("Plants with different refs of the same repository share its cache dir, those are grown one after the other")
+	seedByRef := map[string]string{
+		"v1": "git+file://" + bareDirPth + "//seeds/apples@v1.0.0",
+		"v2": "git+file://" + bareDirPth + "//seeds/apples@master",
+	}
+	gardenMap := config.GardenMapModel{Plants: map[string]config.PlantModel{}}
+	plantIDs := []string{}
+	for _, aRef := range []string{"v1", "v2"} {
+		for idx := 1; idx <= 3; idx++ {
+			plantID := fmt.Sprintf("%s-%d", aRef, idx)
+			plantIDs = append(plantIDs, plantID)
+			gardenMap.Plants[plantID] = config.PlantModel{Path: filepath.Join(tmpDir, "plants", plantID), Seed: seedByRef[aRef]}
+		}
+	}
+
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, plantIDs, growOptionsModel{Jobs: 2}))
+	for _, aPlantID := range plantIDs {
+		expectedContent := "v1\n"
+		if gardenMap.Plants[aPlantID].Seed == seedByRef["v2"] {
+			expectedContent = "v2\n"
+		}
+		testFileContent(t, filepath.Join(gardenMap.Plants[aPlantID].Path, "file.txt"), expectedContent)
+	}
+}
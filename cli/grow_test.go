@@ -5,8 +5,11 @@ import (
 	"log"
 	"os"
 	"path"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/pathutil"
@@ -64,7 +67,7 @@ func Test_growPlants(t *testing.T) {
 	gardenMap = fixPlantPathForTest(gardenMap, absPlantRootPath)
 	t.Logf("-> gardenMap: %#v", gardenMap)
 
-	err = growPlants(absTestGardenDirPath, gardenMap, gardenMap.FilteredPlantsIDs("", ""))
+	err = growPlants(absTestGardenDirPath, gardenMap, gardenMap.FilteredPlantsIDs("", ""), growOptionsModel{})
 	require.NoError(t, err)
 
 	// Apple-1
@@ -84,12 +87,20 @@ PlantID: apple-1
 PlantPath: `+appleOneDirPth+`
 `)
 	// template 2, in a subdir of plant
-	testFileContent(t, path.Join(appleOneDirPth, "subdir", "tempinsub"), `Apples - this is a templated file, in a sub directory.
+	testFileContent(t, path.Join(appleOneDirPth, "subdir", "tempinsub"), `# Managed by garden - plant: apple-1
+Apples - this is a templated file, in a sub directory.
 
 Temp:  T1 |
 Temp: |
 Value of MyVar1: my value - for var 1
+-- yes apples --
 `)
+	// the seed's meta dir should not be copied into the plant
+	{
+		isExist, err := pathutil.IsPathExists(path.Join(appleOneDirPth, ".seed"))
+		require.NoError(t, err)
+		require.False(t, isExist)
+	}
 
 	// Orange-1
 	// test the generated files
@@ -107,6 +118,250 @@ IsItAFruit: this is a fruit
 IsApples: no
 PlantID: orange-1
 PlantPath: `+orangeOneDirPth+`
+PlantExpandedPath: `+orangeOneDirPth+`
+Seed: oranges
+Zones: fruits,oranges
+GardenDir: garden
+Host OS: `+runtime.GOOS+`
+Timestamp set: true
+`)
+	// template with custom delimiters
+	testFileContent(t, path.Join(orangeOneDirPth, "workflow.yml"), `name: my value - for var 1
+run: echo "${{ github.sha }}"
 `)
 
 }
+
+func Test_growPlants_dryRun(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "app", "README.md.template"), "# {{ var \"AppName\" }}\n", 0644)
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "app", "new.txt"), "new\n", 0644)
+
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	writeTestFile(t, filepath.Join(plantDirPth, "README.md"), "# old\n", 0644)
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app-1": config.PlantModel{Path: plantDirPth, Seed: "app", Vars: config.PlantVarsMap{"AppName": "my-app"}},
+		},
+	}
+
+	t.Log("The plant dir is not changed")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsDryRun: true}))
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "# old\n")
+	isExist, err := pathutil.IsPathExists(filepath.Join(plantDirPth, "new.txt"))
+	require.NoError(t, err)
+	require.False(t, isExist)
+
+	t.Log("A dry run fails if the rendering fails")
+	plantModel := gardenMap.Plants["app-1"]
+	plantModel.Vars = config.PlantVarsMap{}
+	gardenMap.Plants["app-1"] = plantModel
+	require.Error(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsDryRun: true}))
+}
+
+func Test_varsHash(t *testing.T) {
+	hash, err := varsHash(map[string]string{"A": "1", "B": "2"})
+	require.NoError(t, err)
+	require.Equal(t, 64, len(hash))
+
+	otherHash, err := varsHash(map[string]string{"B": "2", "A": "1"})
+	require.NoError(t, err)
+	require.Equal(t, hash, otherHash)
+
+	otherHash, err = varsHash(map[string]string{"A": "1", "B": "3"})
+	require.NoError(t, err)
+	require.NotEqual(t, hash, otherHash)
+}
+
+func Test_growPlants_upToDate(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "app", "README.md.template"), "# {{ var \"AppName\" }}\n", 0644)
+
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app-1": config.PlantModel{Path: plantDirPth, Seed: "app", Vars: config.PlantVarsMap{"AppName": "my-app"}},
+		},
+	}
+	lastGrownAt := func() time.Time {
+		gardenState, err := config.LoadGardenState(gardenDirPth)
+		require.NoError(t, err)
+		return gardenState.PlantState("app-1").GrownAt
+	}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	grownAt := lastGrownAt()
+	gardenState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+	require.Equal(t, plantDirPth, gardenState.PlantState("app-1").PlantPath)
+
+	t.Log("Nothing changed - skipped")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.True(t, grownAt.Equal(lastGrownAt()))
+
+	t.Log("Force")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsForce: true}))
+	require.False(t, grownAt.Equal(lastGrownAt()))
+	grownAt = lastGrownAt()
+
+	t.Log("Locally edited file - grown")
+	writeTestFile(t, filepath.Join(plantDirPth, "README.md"), "# my-app\n\nedited\n", 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{ConflictPolicy: config.ConflictPolicyForce}))
+	require.False(t, grownAt.Equal(lastGrownAt()))
+	grownAt = lastGrownAt()
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "# my-app\n")
+
+	t.Log("Changed vars - grown")
+	plantModel := gardenMap.Plants["app-1"]
+	plantModel.Vars = config.PlantVarsMap{"AppName": "renamed"}
+	gardenMap.Plants["app-1"] = plantModel
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.False(t, grownAt.Equal(lastGrownAt()))
+	grownAt = lastGrownAt()
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "# renamed\n")
+
+	t.Log("Changed seed - grown")
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "app", "new.txt"), "new\n", 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.False(t, grownAt.Equal(lastGrownAt()))
+	grownAt = lastGrownAt()
+	testFileContent(t, filepath.Join(plantDirPth, "new.txt"), "new\n")
+
+	t.Log("Changed shared partial - grown")
+	writeTestFile(t, filepath.Join(gardenDirPth, "partials", "shared.tmpl"), `{{ define "shared" }}v1{{ end }}`, 0644)
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "app", "shared.txt.template"), `{{ template "shared" . }}`, 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	grownAt = lastGrownAt()
+	writeTestFile(t, filepath.Join(gardenDirPth, "partials", "shared.tmpl"), `{{ define "shared" }}v2{{ end }}`, 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.False(t, grownAt.Equal(lastGrownAt()))
+	grownAt = lastGrownAt()
+	testFileContent(t, filepath.Join(plantDirPth, "shared.txt"), "v2")
+
+	t.Log("Changed other plant - grown")
+	gardenMap.Plants["api"] = config.PlantModel{Path: filepath.Join(tmpDir, "api"), Seed: "app", Vars: config.PlantVarsMap{"AppName": "api"}}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.False(t, grownAt.Equal(lastGrownAt()))
+	grownAt = lastGrownAt()
+	gardenMap.Plants["api"] = config.PlantModel{Path: filepath.Join(tmpDir, "api"), Seed: "app", Vars: config.PlantVarsMap{"AppName": "api-v2"}}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.False(t, grownAt.Equal(lastGrownAt()))
+	grownAt = lastGrownAt()
+
+	t.Log("Changed zones - grown")
+	plantModel = gardenMap.Plants["app-1"]
+	plantModel.Zones = []string{"apps"}
+	gardenMap.Plants["app-1"] = plantModel
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.False(t, grownAt.Equal(lastGrownAt()))
+	grownAt = lastGrownAt()
+
+	t.Log("Nothing changed - skipped")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.True(t, grownAt.Equal(lastGrownAt()))
+
+	t.Log("Grown by another garden version - grown")
+	gardenState, err = config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+	plantState := gardenState.PlantState("app-1")
+	plantState.GardenVersion = "0.0.1"
+	gardenState.Plants["app-1"] = plantState
+	require.NoError(t, config.SaveGardenState(gardenDirPth, gardenState))
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	require.False(t, grownAt.Equal(lastGrownAt()))
+}
+
+func Test_growSummaryTable(t *testing.T) {
+	results := []plantGrowResultModel{
+		plantGrowResultModel{PlantID: "app-1", Duration: 1234 * time.Millisecond},
+		plantGrowResultModel{PlantID: "api", Duration: 5 * time.Millisecond, Err: fmt.Errorf("Failed to render\ntemplate: README.md:1: unexpected EOF")},
+		plantGrowResultModel{PlantID: "website", IsSkipped: true, Duration: 20 * time.Millisecond},
+	}
+	require.Equal(t, []string{
+		"PLANT    STATUS       DURATION  ERROR",
+		"app-1    succeeded    1.23s",
+		"api      failed       10ms      Failed to render",
+		"website  skipped      20ms",
+		"docs     not started  -",
+	}, growSummaryTable([]string{"app-1", "api", "website", "docs"}, results))
+}
+
+func Test_growPlants_keepGoing(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "app", "README.md.template"), "# {{ .PlantID }}\n", 0644)
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "broken", "README.md.template"), "# {{ .PlantID \n", 0644)
+
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app-1":  config.PlantModel{Path: filepath.Join(tmpDir, "app-1"), Seed: "app"},
+			"broken": config.PlantModel{Path: filepath.Join(tmpDir, "broken"), Seed: "broken"},
+			"web":    config.PlantModel{Path: filepath.Join(tmpDir, "web"), Seed: "app"},
+		},
+	}
+	plantIDs := []string{"app-1", "broken", "web"}
+
+	t.Log("By default no more plants are started after a failure")
+	err = growPlants(gardenDirPth, gardenMap, plantIDs, growOptionsModel{})
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "Failed to grow plant (broken)"), err.Error())
+	testFileContent(t, filepath.Join(tmpDir, "app-1", "README.md"), "# app-1\n")
+	testPathExists(t, filepath.Join(tmpDir, "web"), false)
+
+	t.Log("Keep going - every plant is grown, the failed ones are reported")
+	err = growPlants(gardenDirPth, gardenMap, plantIDs, growOptionsModel{IsKeepGoing: true, IsForce: true})
+	require.EqualError(t, err, "1 of 3 plant(s) failed to grow: broken")
+	testFileContent(t, filepath.Join(tmpDir, "web", "README.md"), "# web\n")
+	testPathExists(t, filepath.Join(tmpDir, "broken"), false)
+
+	gardenState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+	require.Equal(t, []string{"app-1", "web"}, gardenState.PlantIDs())
+}
+
+func Test_evaluatePathTemplates(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	evalContext := templateEvaluationContextModel{
+		Inventory: GardenTemplateInventoryModel{Vars: map[string]string{"AppName": "MyApp"}},
+	}
+
+	t.Log("Dir and file names")
+	okDirPth := filepath.Join(tmpDir, "ok")
+	writeTestFile(t, filepath.Join(okDirPth, "{{ var `AppName` }}", "{{ var `AppName` }}Delegate.swift"), "delegate\n", 0644)
+	writeTestFile(t, filepath.Join(okDirPth, "{{`{{`}}raw}}.txt"), "raw\n", 0644)
+	writeTestFile(t, filepath.Join(okDirPth, "plain.txt"), "plain\n", 0644)
+	require.NoError(t, evaluatePathTemplates(okDirPth, evalContext))
+	testFileContent(t, filepath.Join(okDirPth, "MyApp", "MyAppDelegate.swift"), "delegate\n")
+	testFileContent(t, filepath.Join(okDirPth, "{{raw}}.txt"), "raw\n")
+	testFileContent(t, filepath.Join(okDirPth, "plain.txt"), "plain\n")
+
+	t.Log("Evaluated name outside of the dir")
+	invalidDirPth := filepath.Join(tmpDir, "invalid")
+	writeTestFile(t, filepath.Join(invalidDirPth, "sub", "{{ `..` }}"), "x\n", 0644)
+	require.Error(t, evaluatePathTemplates(invalidDirPth, evalContext))
+	testFileContent(t, filepath.Join(invalidDirPth, "sub", "{{ `..` }}"), "x\n")
+
+	t.Log("Evaluated name already exists")
+	existsDirPth := filepath.Join(tmpDir, "exists")
+	writeTestFile(t, filepath.Join(existsDirPth, "{{ var `AppName` }}.txt"), "new\n", 0644)
+	writeTestFile(t, filepath.Join(existsDirPth, "MyApp.txt"), "old\n", 0644)
+	require.Error(t, evaluatePathTemplates(existsDirPth, evalContext))
+	testFileContent(t, filepath.Join(existsDirPth, "MyApp.txt"), "old\n")
+}
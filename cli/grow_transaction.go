@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// savedPathModel ...
+//  the original version of a path, before a grow changed it
+type savedPathModel struct {
+	// Path : absolute path
+	Path string
+	// IsExisting : false if the path didn't exist before the grow,
+	//  it's removed on rollback
+	IsExisting bool
+	Mode       os.FileMode
+	ModTime    time.Time
+	// LinkTarget : the target of a symlink
+	LinkTarget string
+	// BackupPth : the copy of a regular file, in the transaction's backup dir
+	BackupPth string
+}
+
+// plantTransactionModel ...
+//  makes the changes of a plant's grow revertible: the original version
+//  of every path is saved (into a temporary backup dir) before the grow
+//  creates, changes or removes it, and the saved versions are restored
+//  if the grow fails.
+//  The methods of a nil transaction (e.g. in a dry run) do nothing.
+type plantTransactionModel struct {
+	backupDirPth string
+	savedPaths   []savedPathModel
+	isSaved      map[string]bool
+}
+
+// newPlantTransaction ...
+//  the transaction's backup dir has to be removed with cleanup
+func newPlantTransaction() (*plantTransactionModel, error) {
+	backupDirPth, err := pathutil.NormalizedOSTempDirPath("")
+	if err != nil {
+		return nil, err
+	}
+	return &plantTransactionModel{
+		backupDirPth: backupDirPth,
+		savedPaths:   []savedPathModel{},
+		isSaved:      map[string]bool{},
+	}, nil
+}
+
+// save ...
+//  saves the original version of the path, before it's changed. Only the
+//  first version of a path is saved. If the path doesn't exist the topmost
+//  of its parent dirs which doesn't exist is saved, so the dirs created
+//  for the path are removed on rollback too.
+func (transaction *plantTransactionModel) save(pth string) error {
+	if transaction == nil {
+		return nil
+	}
+	pth = filepath.Clean(pth)
+	fileInfo, err := os.Lstat(pth)
+	if os.IsNotExist(err) {
+		for {
+			parentPth := filepath.Dir(pth)
+			if parentPth == pth {
+				break
+			}
+			if _, err := os.Lstat(parentPth); !os.IsNotExist(err) {
+				break
+			}
+			pth = parentPth
+		}
+		if !transaction.isSaved[pth] {
+			transaction.isSaved[pth] = true
+			transaction.savedPaths = append(transaction.savedPaths, savedPathModel{Path: pth})
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if transaction.isSaved[pth] {
+		return nil
+	}
+
+	savedPath := savedPathModel{
+		Path:       pth,
+		IsExisting: true,
+		Mode:       fileInfo.Mode(),
+		ModTime:    fileInfo.ModTime(),
+	}
+	switch {
+	case fileInfo.IsDir():
+		// the content of the dir is saved path by path
+	case fileInfo.Mode()&os.ModeSymlink != 0:
+		if savedPath.LinkTarget, err = os.Readlink(pth); err != nil {
+			return err
+		}
+	case fileInfo.Mode().IsRegular():
+		savedPath.BackupPth = filepath.Join(transaction.backupDirPth, strconv.Itoa(len(transaction.savedPaths)))
+		if err := copyFileContent(pth, savedPath.BackupPth, fileInfo); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Not a regular file, directory or symlink (path:%s)", pth)
+	}
+	transaction.isSaved[pth] = true
+	transaction.savedPaths = append(transaction.savedPaths, savedPath)
+	return nil
+}
+
+// saveCopyTargets ...
+//  saves the paths which copying the source dir's content
+//  into the target dir (with copyDirContent) would change
+func (transaction *plantTransactionModel) saveCopyTargets(srcDirPth, dstDirPth string) error {
+	if transaction == nil {
+		return nil
+	}
+	if err := transaction.save(dstDirPth); err != nil {
+		return err
+	}
+	plannedResult, err := copyDirContent(srcDirPth, dstDirPth, true)
+	if err != nil {
+		return err
+	}
+	for _, aPath := range plannedResult.Changed() {
+		if err := transaction.save(filepath.Join(dstDirPth, filepath.FromSlash(aPath.Path))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isEmpty ...
+//  whether no path was saved, so there's nothing to roll back
+func (transaction *plantTransactionModel) isEmpty() bool {
+	return transaction == nil || len(transaction.savedPaths) < 1
+}
+
+// restoreSavedPath ...
+func restoreSavedPath(savedPath savedPathModel) error {
+	fileInfo, err := os.Lstat(savedPath.Path)
+	isExisting := true
+	if os.IsNotExist(err) {
+		isExisting = false
+	} else if err != nil {
+		return err
+	}
+	if !savedPath.IsExisting {
+		if !isExisting {
+			return nil
+		}
+		return os.RemoveAll(savedPath.Path)
+	}
+
+	if isExisting {
+		isSameType := fileInfo.Mode()&os.ModeType == savedPath.Mode&os.ModeType
+		// a regular file is restored by renaming the saved content over it,
+		//  a dir's content is restored path by path
+		if !isSameType || fileInfo.Mode()&os.ModeSymlink != 0 {
+			if err := os.RemoveAll(savedPath.Path); err != nil {
+				return err
+			}
+			isExisting = false
+		}
+	}
+
+	switch {
+	case savedPath.Mode.IsDir():
+		if !isExisting {
+			if err := os.Mkdir(savedPath.Path, savedPath.Mode.Perm()); err != nil {
+				return err
+			}
+		}
+		if err := os.Chmod(savedPath.Path, savedPath.Mode.Perm()); err != nil {
+			return err
+		}
+		return os.Chtimes(savedPath.Path, savedPath.ModTime, savedPath.ModTime)
+	case savedPath.Mode&os.ModeSymlink != 0:
+		return os.Symlink(savedPath.LinkTarget, savedPath.Path)
+	}
+	backupInfo, err := os.Lstat(savedPath.BackupPth)
+	if err != nil {
+		return err
+	}
+	return copyFileContent(savedPath.BackupPth, savedPath.Path, backupInfo)
+}
+
+// rollback ...
+//  restores the saved paths, in reverse order. Every path is
+//  restored (even if an other one fails), the first error is returned.
+func (transaction *plantTransactionModel) rollback() error {
+	if transaction == nil {
+		return nil
+	}
+	var rollbackErr error
+	for idx := len(transaction.savedPaths) - 1; idx >= 0; idx-- {
+		savedPath := transaction.savedPaths[idx]
+		if err := restoreSavedPath(savedPath); err != nil && rollbackErr == nil {
+			rollbackErr = fmt.Errorf("Failed to restore (%s), error: %s", savedPath.Path, err)
+		}
+	}
+	return rollbackErr
+}
+
+// cleanup ...
+//  removes the transaction's backup dir
+func (transaction *plantTransactionModel) cleanup() error {
+	if transaction == nil {
+		return nil
+	}
+	return os.RemoveAll(transaction.backupDirPth)
+}
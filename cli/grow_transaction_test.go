@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_plantTransaction(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	writeTestFile(t, filepath.Join(tmpDir, "README.md"), "readme\n", 0600)
+	writeTestFile(t, filepath.Join(tmpDir, "docs", "guide.md"), "guide\n", 0644)
+	writeTestFile(t, filepath.Join(tmpDir, "config"), "config\n", 0644)
+	require.NoError(t, os.Symlink("README.md", filepath.Join(tmpDir, "link")))
+
+	transaction, err := newPlantTransaction()
+	require.NoError(t, err)
+	require.True(t, transaction.isEmpty())
+
+	t.Log("Changes, each path saved before it's changed")
+	for _, aPth := range []string{"README.md", "new/dir/file.txt", "link", "docs/guide.md", "docs", "config"} {
+		require.NoError(t, transaction.save(filepath.Join(tmpDir, aPth)))
+	}
+	writeTestFile(t, filepath.Join(tmpDir, "README.md"), "changed\n", 0755)
+	writeTestFile(t, filepath.Join(tmpDir, "new", "dir", "file.txt"), "new\n", 0644)
+	require.NoError(t, os.Remove(filepath.Join(tmpDir, "link")))
+	require.NoError(t, os.Symlink("docs", filepath.Join(tmpDir, "link")))
+	require.NoError(t, os.RemoveAll(filepath.Join(tmpDir, "docs")))
+	require.NoError(t, os.Remove(filepath.Join(tmpDir, "config")))
+	writeTestFile(t, filepath.Join(tmpDir, "config", "app.yml"), "app\n", 0644)
+	require.False(t, transaction.isEmpty())
+
+	t.Log("Rollback - the previous content is restored, the created paths are removed")
+	require.NoError(t, transaction.rollback())
+	testFileContent(t, filepath.Join(tmpDir, "README.md"), "readme\n")
+	fileInfo, err := os.Stat(filepath.Join(tmpDir, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fileInfo.Mode().Perm())
+	testPathExists(t, filepath.Join(tmpDir, "new"), false)
+	linkTarget, err := os.Readlink(filepath.Join(tmpDir, "link"))
+	require.NoError(t, err)
+	require.Equal(t, "README.md", linkTarget)
+	testFileContent(t, filepath.Join(tmpDir, "docs", "guide.md"), "guide\n")
+	testFileContent(t, filepath.Join(tmpDir, "config"), "config\n")
+
+	t.Log("Cleanup removes the saved versions")
+	require.NoError(t, transaction.cleanup())
+	testPathExists(t, transaction.backupDirPth, false)
+
+	t.Log("A nil transaction does nothing")
+	var nilTransaction *plantTransactionModel
+	require.NoError(t, nilTransaction.save(filepath.Join(tmpDir, "README.md")))
+	require.True(t, nilTransaction.isEmpty())
+	require.NoError(t, nilTransaction.rollback())
+	require.NoError(t, nilTransaction.cleanup())
+}
+
+func Test_growPlants_rollback(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	seedDirPth := filepath.Join(gardenDirPth, "seeds", "app")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "readme v1\n", 0644)
+
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app-1": config.PlantModel{Path: plantDirPth, Seed: "app"},
+		},
+	}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	origGardenState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+
+	t.Log("The grow fails after the plant's files were copied - the plant's previous content is restored")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "readme v2\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "src", "main.go"), "package main\n", 0644)
+	// the merge bases can't be saved
+	mergeBasesDirPth := config.PlantMergeBasesDirPath(gardenDirPth, "app-1")
+	require.NoError(t, os.RemoveAll(mergeBasesDirPth))
+	writeTestFile(t, mergeBasesDirPth, "not a dir\n", 0644)
+
+	// the temp dirs of the grow are created in this dir
+	tmpTmpDir := filepath.Join(tmpDir, "tmp")
+	require.NoError(t, os.MkdirAll(tmpTmpDir, 0755))
+	origTmpDir := os.Getenv("TMPDIR")
+	require.NoError(t, os.Setenv("TMPDIR", tmpTmpDir))
+	growErr := growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{})
+	require.NoError(t, os.Setenv("TMPDIR", origTmpDir))
+
+	require.Error(t, growErr)
+	require.Contains(t, growErr.Error(), "Failed to save the merge bases")
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "readme v1\n")
+	testPathExists(t, filepath.Join(plantDirPth, "src"), false)
+	fileInfos, err := ioutil.ReadDir(tmpTmpDir)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(fileInfos))
+
+	gardenState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+	require.Equal(t, origGardenState, gardenState)
+}
@@ -0,0 +1,23 @@
+package cli
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/codegangsta/cli"
+)
+
+func initGarden(c *cli.Context) {
+	log.Infoln("Init")
+
+	gardenDirPth := "./.garden"
+	if len(c.Args()) > 0 {
+		gardenDirPth = c.Args()[0]
+	}
+
+	gardenDirAbsPth, err := config.InitGardenDir(gardenDirPth)
+	if err != nil {
+		log.Fatalf("Failed to initialize Garden directory: %s", err)
+	}
+	log.Infoln(colorstring.Green("Garden directory created:"), gardenDirAbsPth)
+}
@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/codegangsta/cli"
+)
+
+// varKeyTemplateFunctions : template functions which get a var's key
+//  as their first argument
+var varKeyTemplateFunctions = map[string]bool{
+	"var":         true,
+	"optionalVar": true,
+	"hasVar":      true,
+}
+
+// templateVarUsageModel ...
+//  the vars a seed's templates use
+type templateVarUsageModel struct {
+	VarNames map[string]bool
+	// IsAllVarsUsed : the vars are accessed dynamically
+	//  (e.g. {{ range .Vars }} or {{ var $name }}), any var might be used
+	IsAllVarsUsed bool
+}
+
+func newTemplateVarUsage() templateVarUsageModel {
+	return templateVarUsageModel{VarNames: map[string]bool{}}
+}
+
+// IsUsed ...
+func (usage templateVarUsageModel) IsUsed(varName string) bool {
+	return usage.IsAllVarsUsed || usage.VarNames[varName]
+}
+
+// collectFromFieldIdent ...
+//  `.Vars.X` (ident: Vars, X) or `$.Vars.X` (ident: $, Vars, X)
+func (usage *templateVarUsageModel) collectFromFieldIdent(ident []string) {
+	if len(ident) < 1 || ident[0] != "Vars" {
+		return
+	}
+	if len(ident) == 1 {
+		usage.IsAllVarsUsed = true
+		return
+	}
+	usage.VarNames[ident[1]] = true
+}
+
+func (usage *templateVarUsageModel) collectFromCommand(cmd *parse.CommandNode) {
+	if len(cmd.Args) == 0 {
+		return
+	}
+	identifier, isFunctionCall := cmd.Args[0].(*parse.IdentifierNode)
+	if isFunctionCall {
+		switch {
+		case varKeyTemplateFunctions[identifier.Ident]:
+			if len(cmd.Args) > 1 {
+				if keyNode, ok := cmd.Args[1].(*parse.StringNode); ok {
+					usage.VarNames[keyNode.Text] = true
+				} else {
+					usage.IsAllVarsUsed = true
+				}
+			}
+			for _, anArg := range cmd.Args[2:] {
+				usage.collectFromNode(anArg)
+			}
+			return
+		case identifier.Ident == "index" && len(cmd.Args) > 2:
+			// index .Vars "X"
+			if fieldNode, ok := cmd.Args[1].(*parse.FieldNode); ok && len(fieldNode.Ident) == 1 && fieldNode.Ident[0] == "Vars" {
+				if keyNode, ok := cmd.Args[2].(*parse.StringNode); ok {
+					usage.VarNames[keyNode.Text] = true
+				} else {
+					usage.IsAllVarsUsed = true
+				}
+				for _, anArg := range cmd.Args[3:] {
+					usage.collectFromNode(anArg)
+				}
+				return
+			}
+		}
+	}
+
+	for idx, anArg := range cmd.Args {
+		if _, ok := anArg.(*parse.DotNode); ok && isFunctionCall && idx > 0 {
+			// the dot is passed to a function (e.g. toJSON .),
+			//  it might be the whole inventory
+			usage.IsAllVarsUsed = true
+		}
+		usage.collectFromNode(anArg)
+	}
+}
+
+// collectFromNode ...
+//  walks the template's parse tree
+func (usage *templateVarUsageModel) collectFromNode(node parse.Node) {
+	switch typedNode := node.(type) {
+	case *parse.ListNode:
+		if typedNode == nil {
+			return
+		}
+		for _, aNode := range typedNode.Nodes {
+			usage.collectFromNode(aNode)
+		}
+	case *parse.ActionNode:
+		usage.collectFromNode(typedNode.Pipe)
+	case *parse.IfNode:
+		usage.collectFromBranch(&typedNode.BranchNode)
+	case *parse.RangeNode:
+		usage.collectFromBranch(&typedNode.BranchNode)
+	case *parse.WithNode:
+		usage.collectFromBranch(&typedNode.BranchNode)
+	case *parse.TemplateNode:
+		usage.collectFromNode(typedNode.Pipe)
+	case *parse.PipeNode:
+		if typedNode == nil {
+			return
+		}
+		for _, aCmd := range typedNode.Cmds {
+			usage.collectFromCommand(aCmd)
+		}
+	case *parse.FieldNode:
+		usage.collectFromFieldIdent(typedNode.Ident)
+	case *parse.VariableNode:
+		if len(typedNode.Ident) > 1 && typedNode.Ident[0] == "$" {
+			usage.collectFromFieldIdent(typedNode.Ident[1:])
+		}
+	case *parse.ChainNode:
+		usage.collectFromNode(typedNode.Node)
+	}
+}
+
+func (usage *templateVarUsageModel) collectFromBranch(branch *parse.BranchNode) {
+	usage.collectFromNode(branch.Pipe)
+	usage.collectFromNode(branch.List)
+	usage.collectFromNode(branch.ElseList)
+}
+
+// collectFromTemplate ...
+//  parses the template content (and its {{ define }} blocks)
+//  and collects the vars it uses
+func (usage *templateVarUsageModel) collectFromTemplate(name, content string, delimiters templateDelimitersModel) error {
+	if fileDelimiters, fileContent, isFound := extractDelimitersDirective(content); isFound {
+		delimiters = fileDelimiters
+		content = fileContent
+	}
+	tmpl, err := template.New(name).
+		Delims(delimiters.Left, delimiters.Right).
+		Funcs(templateEvaluationContextModel{}.templateFunctions()).
+		Parse(content)
+	if err != nil {
+		return err
+	}
+	for _, aTmpl := range tmpl.Templates() {
+		if aTmpl.Tree != nil {
+			usage.collectFromNode(aTmpl.Tree.Root)
+		}
+	}
+	return nil
+}
+
+// collectSeedVarUsage ...
+//  the vars used by the seed's template files, file and directory names
+//  and by the template partials (of the garden and of the seed)
+func collectSeedVarUsage(gardenDirAbsPth, seedDirPth string) (templateVarUsageModel, error) {
+	usage := newTemplateVarUsage()
+	seedConfig, err := config.LoadSeedConfig(seedDirPth)
+	if err != nil {
+		return templateVarUsageModel{}, fmt.Errorf("Failed to load seed config, error: %s", err)
+	}
+	delimiters := templateDelimitersModel{}
+	if len(seedConfig.TemplateDelimiters) == 2 {
+		delimiters = templateDelimitersModel{Left: seedConfig.TemplateDelimiters[0], Right: seedConfig.TemplateDelimiters[1]}
+	}
+
+	partials, err := loadTemplatePartials(
+		filepath.Join(gardenDirAbsPth, partialsDirName),
+		filepath.Join(seedDirPth, config.SeedMetaDirName, partialsDirName))
+	if err != nil {
+		return templateVarUsageModel{}, fmt.Errorf("Failed to load template partials, error: %s", err)
+	}
+	for _, aPartial := range partials.Files {
+		if err := usage.collectFromTemplate(aPartial.Path, aPartial.Content, aPartial.Delimiters); err != nil {
+			return templateVarUsageModel{}, fmt.Errorf("Failed to parse partial file (path:%s), error: %s", aPartial.Path, err)
+		}
+	}
+
+	leftDelimiter := delimiters.Left
+	if leftDelimiter == "" {
+		leftDelimiter = "{{"
+	}
+	err = filepath.Walk(seedDirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if pth == seedDirPth {
+			return nil
+		}
+		if fileInfo.IsDir() && (fileInfo.Name() == config.SeedMetaDirName || fileInfo.Name() == ".git") {
+			return filepath.SkipDir
+		}
+		if strings.Contains(fileInfo.Name(), leftDelimiter) {
+			if err := usage.collectFromTemplate(pth, fileInfo.Name(), delimiters); err != nil {
+				return fmt.Errorf("Failed to parse the name of path (%s), error: %s", pth, err)
+			}
+		}
+		if fileInfo.IsDir() {
+			return nil
+		}
+		if filepath.Ext(pth) != ".template" {
+			return nil
+		}
+		content, err := fileutil.ReadStringFromFile(pth)
+		if err != nil {
+			return err
+		}
+		if err := usage.collectFromTemplate(pth, content, delimiters); err != nil {
+			return fmt.Errorf("Failed to parse template (path:%s), error: %s", pth, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return templateVarUsageModel{}, err
+	}
+	return usage, nil
+}
+
+// plantLintResultModel ...
+type plantLintResultModel struct {
+	PlantID string
+	// UnusedVars : vars the plant defines, but its seed doesn't use
+	UnusedVars []string
+	// HostDependentCalls : the seed's templates which call host dependent
+	//  template functions (only a warning, not an issue)
+	HostDependentCalls []string
+}
+
+// lintPlants ...
+//  reports the vars which are defined by a plant (zone vars are not included,
+//  those are shared by multiple plants), but not used by any of its seed's templates
+func lintPlants(gardenDirAbsPth string, gardenMap config.GardenMapModel, plantIDs []string) ([]plantLintResultModel, error) {
+	gardenLock, err := config.LoadGardenLock(gardenDirAbsPth)
+	if err != nil {
+		return []plantLintResultModel{}, fmt.Errorf("Failed to load garden lock, error: %s", err)
+	}
+
+	sortedPlantIDs := append([]string{}, plantIDs...)
+	sort.Strings(sortedPlantIDs)
+
+	usageBySeedDir := map[string]templateVarUsageModel{}
+	hostDependentCallsBySeedDir := map[string][]string{}
+	results := []plantLintResultModel{}
+	for _, aPlantID := range sortedPlantIDs {
+		plantModel, isFound := gardenMap.Plants[aPlantID]
+		if !isFound {
+			return []plantLintResultModel{}, fmt.Errorf("Can't find Plant with ID: %s", aPlantID)
+		}
+		// the lock is not saved, linting doesn't lock new seeds
+		resolvedSeed, err := resolveLockedSeed(gardenDirAbsPth, plantModel, gardenLock, false, log.StandardLogger())
+		if err != nil {
+			return []plantLintResultModel{}, fmt.Errorf("Failed to check seed of plant (%s), error: %s", aPlantID, err)
+		}
+
+		usage, isFound := usageBySeedDir[resolvedSeed.Dir]
+		if !isFound {
+			usage, err = collectSeedVarUsage(gardenDirAbsPth, resolvedSeed.Dir)
+			if err != nil {
+				return []plantLintResultModel{}, fmt.Errorf("Failed to collect the vars used by seed (%s), error: %s", plantModel.Seed, err)
+			}
+			usageBySeedDir[resolvedSeed.Dir] = usage
+		}
+		hostDependentCalls, isFound := hostDependentCallsBySeedDir[resolvedSeed.Dir]
+		if !isFound {
+			hostDependentCalls, err = hostDependentFunctionCalls(gardenDirAbsPth, resolvedSeed.Dir)
+			if err != nil {
+				return []plantLintResultModel{}, fmt.Errorf("Failed to check the templates of seed (%s), error: %s", plantModel.Seed, err)
+			}
+			hostDependentCallsBySeedDir[resolvedSeed.Dir] = hostDependentCalls
+		}
+
+		result := plantLintResultModel{PlantID: aPlantID, UnusedVars: []string{}, HostDependentCalls: hostDependentCalls}
+		for aVarName := range plantModel.Vars {
+			if !usage.IsUsed(aVarName) {
+				result.UnusedVars = append(result.UnusedVars, aVarName)
+			}
+		}
+		sort.Strings(result.UnusedVars)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func lint(c *cli.Context) {
+	log.Infoln("Lint")
+
+	gardenMap, gardenDirAbsPth, err := config.LoadGardenMap("")
+	if err != nil {
+		log.Fatalf("Failed to load Garden Map: %s", err)
+	}
+
+	plantIDs := gardenMap.FilteredPlantsIDs(WorkWithPlantID, WorkWithZone)
+	if len(plantIDs) < 1 {
+		log.Fatalln("No plants to lint!")
+	}
+	results, err := lintPlants(gardenDirAbsPth, gardenMap, plantIDs)
+	if err != nil {
+		log.Fatalf("Failed to lint plants: %s", err)
+	}
+
+	issueCount := 0
+	fmt.Println()
+	for _, aResult := range results {
+		for _, aCall := range aResult.HostDependentCalls {
+			log.Warnf("%s %s: depends on the environment (the output can differ on an other host): %s", colorstring.Yellow("[WARNING]"), aResult.PlantID, aCall)
+		}
+		if len(aResult.UnusedVars) == 0 {
+			log.Infoln(colorstring.Green("[OK]"), aResult.PlantID)
+			continue
+		}
+		issueCount += len(aResult.UnusedVars)
+		log.Warnf("%s %s: unused vars: %s", colorstring.Yellow("[WARNING]"), aResult.PlantID, strings.Join(aResult.UnusedVars, ", "))
+	}
+	if issueCount > 0 {
+		log.Fatalf("%d unused var(s) found", issueCount)
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_templateVarUsageModel_collectFromTemplate(t *testing.T) {
+	usage := newTemplateVarUsage()
+	require.NoError(t, usage.collectFromTemplate("test", `{{ .Vars.A }}
+{{ var "B" | upper }}
+{{ if hasVar "C" }}{{ index .Vars "D" }}{{ else }}{{ $.Vars.E }}{{ end }}
+{{ range .Zones }}{{ $.Vars.F }}{{ end }}
+{{ define "partial" }}{{ with .Vars.G }}{{ . }}{{ end }}{{ end }}
+{{ .PlantID }} {{ default "x" .Vars.H }} {{ optionalVar "I" | default "x" }}`, templateDelimitersModel{}))
+	require.Equal(t, map[string]bool{"A": true, "B": true, "C": true, "D": true, "E": true, "F": true, "G": true, "H": true, "I": true}, usage.VarNames)
+	require.False(t, usage.IsAllVarsUsed)
+	require.False(t, usage.IsUsed("Unused"))
+
+	t.Log("Custom delimiters")
+	usage = newTemplateVarUsage()
+	require.NoError(t, usage.collectFromTemplate("test", `# garden:delims [[ ]]
+[[ var "A" ]] {{ var "B" }}`, templateDelimitersModel{}))
+	require.Equal(t, map[string]bool{"A": true}, usage.VarNames)
+
+	t.Log("Dynamic access - every var might be used")
+	for _, aContent := range []string{
+		`{{ range $k, $v := .Vars }}{{ $k }}{{ end }}`,
+		`{{ $name := "A" }}{{ var $name }}`,
+		`{{ toJSON . }}`,
+		`{{ index .Vars .PlantID }}`,
+	} {
+		usage = newTemplateVarUsage()
+		require.NoError(t, usage.collectFromTemplate("test", aContent, templateDelimitersModel{}))
+		require.True(t, usage.IsAllVarsUsed, aContent)
+		require.True(t, usage.IsUsed("Unused"))
+	}
+
+	t.Log("Invalid template")
+	usage = newTemplateVarUsage()
+	require.Error(t, usage.collectFromTemplate("test", `{{ .Vars.A `, templateDelimitersModel{}))
+}
+
+func Test_lintPlants(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	writeTestFile(t, filepath.Join(gardenDirPth, "partials", "shared.tmpl"), `{{ define "shared" }}{{ var "FromPartial" }}{{ end }}`, 0644)
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "pears", "README.md.template"), `{{ var "Used" }} {{ template "shared" . }}`, 0644)
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "pears", "plain.txt"), `{{ var "NotATemplate" }}`, 0644)
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "pears", "{{ var `InDirName` }}", "{{ var `InFileName` }}.txt"), "", 0644)
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "pears", ".seed", "tests", "basic", "golden", "x.template"), `{{ var "InTests" }}`, 0644)
+	writeTestFile(t, filepath.Join(gardenDirPth, "seeds", "dynamic", "all.template"), `{{ toYAML .Vars }} {{ env "HOME" }}`, 0644)
+
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"pear-1": config.PlantModel{
+				Seed:  "pears",
+				Vars:  config.PlantVarsMap{"Used": "x", "FromPartial": "x", "NotATemplate": "x", "InTests": "x", "Unused": "x", "InDirName": "x", "InFileName": "x"},
+				Zones: []string{"fruits"},
+			},
+			"dynamic-1": config.PlantModel{
+				Seed: "dynamic",
+				Vars: config.PlantVarsMap{"Anything": "x"},
+			},
+		},
+		Zones: map[string]config.ZoneModel{
+			"fruits": config.ZoneModel{Vars: config.PlantVarsMap{"ZoneVar": "x"}},
+		},
+	}
+
+	results, err := lintPlants(gardenDirPth, gardenMap, []string{"pear-1", "dynamic-1"})
+	require.NoError(t, err)
+	require.Equal(t, []plantLintResultModel{
+		{PlantID: "dynamic-1", UnusedVars: []string{}, HostDependentCalls: []string{"all.template: env"}},
+		{PlantID: "pear-1", UnusedVars: []string{"InTests", "NotATemplate", "Unused"}, HostDependentCalls: []string{}},
+	}, results)
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/sliceutil"
+	"github.com/codegangsta/cli"
+)
+
+// resolveLockedSeed ...
+//  resolves the plant's seed, using the locked revision of remote seeds.
+//  If the seed is not yet locked (or isUpdate is true) the seed is resolved
+//  from its reference, and the result is recorded in gardenLock.
+//  If the seed is locked its content is verified against the locked content hash.
+func resolveLockedSeed(gardenDirAbsPth string, plantModel config.PlantModel, gardenLock config.GardenLockModel, isUpdate bool, logger *log.Logger) (resolvedSeedModel, error) {
+	seedRef, err := config.ParseSeedRef(plantModel.Seed)
+	if err != nil {
+		return resolvedSeedModel{}, err
+	}
+	if !seedRef.IsRemote() {
+		return resolveSeed(gardenDirAbsPth, plantModel.Seed, "", "", logger)
+	}
+
+	lockedSeed, isInLock := gardenLock.Seeds[plantModel.Seed]
+	isLocked := isInLock && !isUpdate
+	if isLocked && seedRef.Source == config.SeedSourceArchive && lockedSeed.Revision != plantModel.SeedSHA256 {
+		// the lock is stale: the map's checksum is used
+		isLocked = false
+	}
+
+	lockedRevision := ""
+	if isLocked && seedRef.Source == config.SeedSourceGit {
+		lockedRevision = lockedSeed.Revision
+	}
+	resolvedSeed, err := resolveSeed(gardenDirAbsPth, plantModel.Seed, plantModel.SeedSHA256, lockedRevision, logger)
+	if err != nil {
+		return resolvedSeedModel{}, err
+	}
+	contentHash, err := seedContentHash(resolvedSeed.Dir)
+	if err != nil {
+		return resolvedSeedModel{}, err
+	}
+
+	if isLocked {
+		if lockedSeed.ContentHash != contentHash {
+			return resolvedSeedModel{}, fmt.Errorf("Content of seed (%s) doesn't match the locked content hash - if the change is expected run `garden lock --update` to update the lock", plantModel.Seed)
+		}
+		return resolvedSeed, nil
+	}
+
+	if !isInLock || isUpdate {
+		logger.Infof(" -> Locking seed (%s) at revision: %s", plantModel.Seed, resolvedSeed.Revision)
+		gardenLock.Seeds[plantModel.Seed] = config.LockedSeedModel{
+			Revision:    resolvedSeed.Revision,
+			ContentHash: contentHash,
+		}
+	}
+	return resolvedSeed, nil
+}
+
+// warnIfGardenLockIsStale ...
+func warnIfGardenLockIsStale(gardenMap config.GardenMapModel, gardenLock config.GardenLockModel) {
+	staleSeeds := gardenLock.StaleSeeds(gardenMap)
+	if len(staleSeeds) < 1 {
+		return
+	}
+	log.Warnf("The garden lock (%s) is out of date compared to the garden map, run `garden lock` to update it.", config.GardenLockFileName)
+	log.Warnf("Stale seeds: %s", strings.Join(staleSeeds, ", "))
+}
+
+// lockSeeds ...
+//  locks the remote seeds of the garden map:
+//  seeds which are not yet locked are resolved and added to the lock,
+//  already locked seeds are verified, and seeds which are not used
+//  by any plant are removed from the lock.
+//  If isUpdate is true the seeds are resolved again from their references,
+//  seedsToUpdate can be used to update only the specified seeds.
+func lockSeeds(gardenDirAbsPth string, gardenMap config.GardenMapModel, isUpdate bool, seedsToUpdate []string) (config.GardenLockModel, error) {
+	gardenLock, err := config.LoadGardenLock(gardenDirAbsPth)
+	if err != nil {
+		return config.GardenLockModel{}, fmt.Errorf("Failed to load garden lock, error: %s", err)
+	}
+
+	remoteSeeds := gardenMap.RemoteSeeds()
+	for _, aSeed := range seedsToUpdate {
+		if _, isFound := remoteSeeds[aSeed]; !isFound {
+			return config.GardenLockModel{}, fmt.Errorf("No plant uses the remote seed: %s", aSeed)
+		}
+	}
+
+	staleSeeds := gardenLock.StaleSeeds(gardenMap)
+	newLock := config.NewGardenLock()
+	for seed, lockedSeed := range gardenLock.Seeds {
+		if sliceutil.IndexOfStringInSlice(seed, staleSeeds) >= 0 {
+			log.Infof(" -> Removing stale seed from the lock: %s", seed)
+			continue
+		}
+		newLock.Seeds[seed] = lockedSeed
+	}
+
+	seeds := []string{}
+	for aSeed := range remoteSeeds {
+		seeds = append(seeds, aSeed)
+	}
+	sort.Strings(seeds)
+
+	for _, aSeed := range seeds {
+		isUpdateSeed := isUpdate && (len(seedsToUpdate) == 0 || sliceutil.IndexOfStringInSlice(aSeed, seedsToUpdate) >= 0)
+		log.Infoln(colorstring.Yellow("==> locking seed:"), aSeed)
+		if _, err := resolveLockedSeed(gardenDirAbsPth, remoteSeeds[aSeed], newLock, isUpdateSeed, log.StandardLogger()); err != nil {
+			return config.GardenLockModel{}, err
+		}
+	}
+
+	if err := config.SaveGardenLock(gardenDirAbsPth, newLock); err != nil {
+		return config.GardenLockModel{}, err
+	}
+	return newLock, nil
+}
+
+func lock(c *cli.Context) {
+	log.Infoln("Lock")
+
+	gardenMap, gardenDirAbsPth, err := config.LoadGardenMap("")
+	if err != nil {
+		log.Fatalf("Failed to load Garden Map: %s", err)
+	}
+
+	isUpdate := c.Bool(UpdateKey)
+	seedsToUpdate := []string(c.Args())
+	if len(seedsToUpdate) > 0 && !isUpdate {
+		log.Fatalln("Seeds can only be specified with --update")
+	}
+
+	gardenLock, err := lockSeeds(gardenDirAbsPth, gardenMap, isUpdate, seedsToUpdate)
+	if err != nil {
+		log.Fatalf("Failed to lock seeds: %s", err)
+	}
+	log.Infof("%d seed(s) locked in: %s", len(gardenLock.Seeds), config.GardenLockFilePath(gardenDirAbsPth))
+}
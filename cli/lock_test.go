@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_seedContentHash(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ".git"), 0777))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "file.txt"), "content\n"))
+
+	origHash, err := seedContentHash(tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, 64, len(origHash))
+
+	t.Log("The .git dir is ignored")
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, ".git", "HEAD"), "ref\n"))
+	contentHash, err := seedContentHash(tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, origHash, contentHash)
+
+	t.Log("Executable flag changes the hash")
+	require.NoError(t, os.Chmod(filepath.Join(tmpDir, "file.txt"), 0755))
+	contentHash, err = seedContentHash(tmpDir)
+	require.NoError(t, err)
+	require.NotEqual(t, origHash, contentHash)
+
+	t.Log("Content changes the hash")
+	require.NoError(t, os.Chmod(filepath.Join(tmpDir, "file.txt"), 0644))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "file.txt"), "changed\n"))
+	contentHash, err = seedContentHash(tmpDir)
+	require.NoError(t, err)
+	require.NotEqual(t, origHash, contentHash)
+}
+
+func Test_lockSeeds(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	require.NoError(t, os.MkdirAll(gardenDirPth, 0777))
+	bareDirPth, workDirPth := createTestSeedRepository(t, tmpDir)
+
+	seed := "git+file://" + bareDirPth + "//seeds/apples@master"
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"git-1": config.PlantModel{Path: plantDirPth, Seed: seed},
+			"local": config.PlantModel{Path: filepath.Join(tmpDir, "local"), Seed: "apples"},
+		},
+	}
+
+	t.Log("The first grow locks the seed")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"git-1"}, growOptionsModel{}))
+	gardenLock, err := config.LoadGardenLock(gardenDirPth)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(gardenLock.Seeds))
+	v1LockedSeed := gardenLock.Seeds[seed]
+	require.Equal(t, 40, len(v1LockedSeed.Revision))
+
+	t.Log("New commit on the branch - grow still uses the locked revision")
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(workDirPth, "seeds", "apples", "file.txt"), "v2\n"))
+	gitCommitForTest(t, workDirPth, "v2")
+	_, err = runGitCommand(workDirPth, "push", "--quiet", "origin", "master")
+	require.NoError(t, err)
+
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"git-1"}, growOptionsModel{}))
+	testFileContent(t, filepath.Join(plantDirPth, "file.txt"), "v1\n")
+
+	t.Log("Lock without update - the locked revision is kept, unused seeds are removed")
+	gardenLock.Seeds["git+file:///no/longer/used"] = config.LockedSeedModel{Revision: "abc"}
+	require.NoError(t, config.SaveGardenLock(gardenDirPth, gardenLock))
+	gardenLock, err = lockSeeds(gardenDirPth, gardenMap, false, []string{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]config.LockedSeedModel{seed: v1LockedSeed}, gardenLock.Seeds)
+
+	t.Log("Update an unknown seed")
+	_, err = lockSeeds(gardenDirPth, gardenMap, true, []string{"git+file:///not/used"})
+	require.EqualError(t, err, "No plant uses the remote seed: git+file:///not/used")
+
+	t.Log("Update")
+	gardenLock, err = lockSeeds(gardenDirPth, gardenMap, true, []string{seed})
+	require.NoError(t, err)
+	require.NotEqual(t, v1LockedSeed.Revision, gardenLock.Seeds[seed].Revision)
+	require.NotEqual(t, v1LockedSeed.ContentHash, gardenLock.Seeds[seed].ContentHash)
+
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"git-1"}, growOptionsModel{}))
+	testFileContent(t, filepath.Join(plantDirPth, "file.txt"), "v2\n")
+
+	t.Log("Content doesn't match the lock")
+	lockedSeed := gardenLock.Seeds[seed]
+	lockedSeed.ContentHash = v1LockedSeed.ContentHash
+	gardenLock.Seeds[seed] = lockedSeed
+	require.NoError(t, config.SaveGardenLock(gardenDirPth, gardenLock))
+	err = growPlants(gardenDirPth, gardenMap, []string{"git-1"}, growOptionsModel{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "doesn't match the locked content hash")
+}
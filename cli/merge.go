@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+const (
+	mergeConflictStartMarker     = "<<<<<<< local"
+	mergeConflictSeparatorMarker = "======="
+	mergeConflictEndMarker       = ">>>>>>> seed"
+)
+
+// splitLinesWithEndings ...
+//  the lines of the content, each with its line ending
+//  (the last line might not have one)
+func splitLinesWithEndings(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// matchedLineIndexes ...
+//  the index of the matching line in b, by line index in a.
+//  The automatic junk heuristic is disabled, frequent lines
+//  (e.g. empty lines) of long files have to be matched too.
+func matchedLineIndexes(a, b []string) map[int]int {
+	matches := map[int]int{}
+	matcher := difflib.NewMatcherWithJunk(a, b, false, nil)
+	for _, aBlock := range matcher.GetMatchingBlocks() {
+		for idx := 0; idx < aBlock.Size; idx++ {
+			matches[aBlock.A+idx] = aBlock.B + idx
+		}
+	}
+	return matches
+}
+
+func isSameLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// appendConflictSide ...
+//  the marker after the side has to start in a new line
+func appendConflictSide(lines, sideLines []string) []string {
+	lines = append(lines, sideLines...)
+	if lastLine := lines[len(lines)-1]; !strings.HasSuffix(lastLine, "\n") {
+		lines[len(lines)-1] = lastLine + "\n"
+	}
+	return lines
+}
+
+// mergeChunk ...
+//  resolves a chunk where the local and the seed version might differ
+//  from the base; returns the merged lines, and whether it's a conflict
+func mergeChunk(baseLines, localLines, seedLines []string) ([]string, bool) {
+	switch {
+	case isSameLines(localLines, seedLines), isSameLines(baseLines, seedLines):
+		return localLines, false
+	case isSameLines(baseLines, localLines):
+		return seedLines, false
+	}
+
+	lines := []string{mergeConflictStartMarker + "\n"}
+	lines = appendConflictSide(lines, localLines)
+	lines = append(lines, mergeConflictSeparatorMarker+"\n")
+	lines = appendConflictSide(lines, seedLines)
+	lines = append(lines, mergeConflictEndMarker+"\n")
+	return lines, true
+}
+
+// mergeThreeWay ...
+//  merges the changes of the local and of the seed version (compared to
+//  the base version) line by line. Where both changed the same lines
+//  conflict markers are written, with the local lines first.
+//  Returns the merged content, and whether it has conflicts.
+func mergeThreeWay(baseContent, localContent, seedContent string) (string, bool) {
+	baseLines := splitLinesWithEndings(baseContent)
+	localLines := splitLinesWithEndings(localContent)
+	seedLines := splitLinesWithEndings(seedContent)
+	localMatches := matchedLineIndexes(baseLines, localLines)
+	seedMatches := matchedLineIndexes(baseLines, seedLines)
+
+	mergedLines := []string{}
+	hasConflicts := false
+	addChunk := func(baseChunk, localChunk, seedChunk []string) {
+		lines, isConflict := mergeChunk(baseChunk, localChunk, seedChunk)
+		mergedLines = append(mergedLines, lines...)
+		hasConflicts = hasConflicts || isConflict
+	}
+
+	baseIdx, localIdx, seedIdx := 0, 0, 0
+	for {
+		// the lines which are unchanged in both versions
+		stableCount := 0
+		for baseIdx+stableCount < len(baseLines) {
+			localMatch, isLocalMatch := localMatches[baseIdx+stableCount]
+			seedMatch, isSeedMatch := seedMatches[baseIdx+stableCount]
+			if !isLocalMatch || !isSeedMatch || localMatch != localIdx+stableCount || seedMatch != seedIdx+stableCount {
+				break
+			}
+			stableCount++
+		}
+		if stableCount > 0 {
+			mergedLines = append(mergedLines, baseLines[baseIdx:baseIdx+stableCount]...)
+			baseIdx += stableCount
+			localIdx += stableCount
+			seedIdx += stableCount
+			continue
+		}
+
+		// the next base line which is kept by both versions
+		//  closes the changed chunk
+		nextBaseIdx := -1
+		for idx := baseIdx; idx < len(baseLines); idx++ {
+			localMatch, isLocalMatch := localMatches[idx]
+			seedMatch, isSeedMatch := seedMatches[idx]
+			if isLocalMatch && isSeedMatch && localMatch >= localIdx && seedMatch >= seedIdx {
+				nextBaseIdx = idx
+				break
+			}
+		}
+		if nextBaseIdx < 0 {
+			if baseIdx < len(baseLines) || localIdx < len(localLines) || seedIdx < len(seedLines) {
+				addChunk(baseLines[baseIdx:], localLines[localIdx:], seedLines[seedIdx:])
+			}
+			break
+		}
+		nextLocalIdx, nextSeedIdx := localMatches[nextBaseIdx], seedMatches[nextBaseIdx]
+		addChunk(baseLines[baseIdx:nextBaseIdx], localLines[localIdx:nextLocalIdx], seedLines[seedIdx:nextSeedIdx])
+		baseIdx, localIdx, seedIdx = nextBaseIdx, nextLocalIdx, nextSeedIdx
+	}
+	return strings.Join(mergedLines, ""), hasConflicts
+}
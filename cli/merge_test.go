@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mergeThreeWay(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+
+	t.Log("Only one side changed")
+	merged, hasConflicts := mergeThreeWay(base, base, "a\nB\nc\nd\ne\n")
+	require.False(t, hasConflicts)
+	require.Equal(t, "a\nB\nc\nd\ne\n", merged)
+	merged, hasConflicts = mergeThreeWay(base, "a\nb\nc\nd\ne\nlocal\n", base)
+	require.False(t, hasConflicts)
+	require.Equal(t, "a\nb\nc\nd\ne\nlocal\n", merged)
+
+	t.Log("Both sides changed different lines")
+	merged, hasConflicts = mergeThreeWay(base, "local\na\nb\nc\nd\ne\n", "a\nb\nc\nD\ne\n")
+	require.False(t, hasConflicts)
+	require.Equal(t, "local\na\nb\nc\nD\ne\n", merged)
+	merged, hasConflicts = mergeThreeWay(base, "a\nb\nc\ne\n", "a\nB\nc\nd\ne\n")
+	require.False(t, hasConflicts)
+	require.Equal(t, "a\nB\nc\ne\n", merged)
+
+	t.Log("Both sides made the same change")
+	merged, hasConflicts = mergeThreeWay(base, "a\nB\nc\nd\ne\n", "a\nB\nc\nd\ne\n")
+	require.False(t, hasConflicts)
+	require.Equal(t, "a\nB\nc\nd\ne\n", merged)
+
+	t.Log("Both sides changed the same line")
+	merged, hasConflicts = mergeThreeWay(base, "a\nlocal\nc\nd\nE\n", "a\nseed\nc\nd\ne\n")
+	require.True(t, hasConflicts)
+	require.Equal(t, "a\n<<<<<<< local\nlocal\n=======\nseed\n>>>>>>> seed\nc\nd\nE\n", merged)
+
+	t.Log("Conflict at the end, without trailing newlines")
+	merged, hasConflicts = mergeThreeWay("a\nb", "a\nlocal", "a\nseed")
+	require.True(t, hasConflicts)
+	require.Equal(t, "a\n<<<<<<< local\nlocal\n=======\nseed\n>>>>>>> seed\n", merged)
+
+	t.Log("Empty base")
+	merged, hasConflicts = mergeThreeWay("", "local\n", "seed\n")
+	require.True(t, hasConflicts)
+	require.Equal(t, "<<<<<<< local\nlocal\n=======\nseed\n>>>>>>> seed\n", merged)
+	merged, hasConflicts = mergeThreeWay("", "", "seed\n")
+	require.False(t, hasConflicts)
+	require.Equal(t, "seed\n", merged)
+}
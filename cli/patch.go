@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeaderRegexp = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// patchHunkModel ...
+//  a single hunk of a unified diff
+type patchHunkModel struct {
+	OldStart int
+	OldLines []string
+	NewLines []string
+	// OldNoEOL / NewNoEOL : "\ No newline at end of file" was specified
+	//  for the old / new side's last line
+	OldNoEOL bool
+	NewNoEOL bool
+}
+
+func (hunk patchHunkModel) reversed() patchHunkModel {
+	return patchHunkModel{
+		OldStart: hunk.OldStart,
+		OldLines: hunk.NewLines,
+		NewLines: hunk.OldLines,
+		OldNoEOL: hunk.NewNoEOL,
+		NewNoEOL: hunk.OldNoEOL,
+	}
+}
+
+// splitLines ...
+//  splits the content into lines, and reports whether the
+//  content ended with a new line
+func splitLines(content string) ([]string, bool) {
+	if content == "" {
+		return []string{}, false
+	}
+	hasTrailingNewline := strings.HasSuffix(content, "\n")
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n"), hasTrailingNewline
+}
+
+func joinLines(lines []string, hasTrailingNewline bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	content := strings.Join(lines, "\n")
+	if hasTrailingNewline {
+		content += "\n"
+	}
+	return content
+}
+
+// parseUnifiedDiff ...
+//  parses the hunks of a single file unified diff,
+//  file headers (---, +++ and git's extended headers) are ignored
+func parseUnifiedDiff(patchContent string) ([]patchHunkModel, error) {
+	hunks := []patchHunkModel{}
+	lines, _ := splitLines(patchContent)
+
+	for idx := 0; idx < len(lines); idx++ {
+		matches := hunkHeaderRegexp.FindStringSubmatch(lines[idx])
+		if matches == nil {
+			continue
+		}
+
+		oldStart, _ := strconv.Atoi(matches[1])
+		oldCount, newCount := 1, 1
+		if matches[2] != "" {
+			oldCount, _ = strconv.Atoi(matches[2])
+		}
+		if matches[4] != "" {
+			newCount, _ = strconv.Atoi(matches[4])
+		}
+
+		hunk := patchHunkModel{OldStart: oldStart}
+		lastSide := ""
+		for idx+1 < len(lines) && (len(hunk.OldLines) < oldCount || len(hunk.NewLines) < newCount || strings.HasPrefix(lines[idx+1], `\`)) {
+			idx++
+			line := lines[idx]
+			if strings.HasPrefix(line, `\`) {
+				// \ No newline at end of file
+				switch lastSide {
+				case "-":
+					hunk.OldNoEOL = true
+				case "+":
+					hunk.NewNoEOL = true
+				default:
+					hunk.OldNoEOL = true
+					hunk.NewNoEOL = true
+				}
+				continue
+			}
+
+			if line == "" {
+				// some tools strip the space of empty context lines
+				line = " "
+			}
+			switch line[0] {
+			case ' ':
+				hunk.OldLines = append(hunk.OldLines, line[1:])
+				hunk.NewLines = append(hunk.NewLines, line[1:])
+			case '-':
+				hunk.OldLines = append(hunk.OldLines, line[1:])
+			case '+':
+				hunk.NewLines = append(hunk.NewLines, line[1:])
+			default:
+				return []patchHunkModel{}, fmt.Errorf("Invalid line in hunk #%d: %s", len(hunks)+1, line)
+			}
+			lastSide = line[:1]
+		}
+
+		if len(hunk.OldLines) != oldCount || len(hunk.NewLines) != newCount {
+			return []patchHunkModel{}, fmt.Errorf("Hunk #%d is incomplete", len(hunks)+1)
+		}
+		hunks = append(hunks, hunk)
+	}
+
+	if len(hunks) == 0 {
+		return []patchHunkModel{}, errors.New("No hunks found in patch")
+	}
+	return hunks, nil
+}
+
+func isLinesMatchAt(lines []string, at int, expected []string) bool {
+	if at < 0 || at+len(expected) > len(lines) {
+		return false
+	}
+	for idx, aLine := range expected {
+		if lines[at+idx] != aLine {
+			return false
+		}
+	}
+	return true
+}
+
+// findHunkPosition ...
+//  finds the position where the hunk's old lines match, searching
+//  outward from the expected position
+func findHunkPosition(lines []string, expectedAt int, hunkLines []string) int {
+	for distance := 0; distance <= len(lines); distance++ {
+		if isLinesMatchAt(lines, expectedAt-distance, hunkLines) {
+			return expectedAt - distance
+		}
+		if distance > 0 && isLinesMatchAt(lines, expectedAt+distance, hunkLines) {
+			return expectedAt + distance
+		}
+	}
+	return -1
+}
+
+func applyHunks(content string, hunks []patchHunkModel) (string, error) {
+	lines, hasTrailingNewline := splitLines(content)
+
+	offset := 0
+	for idx, aHunk := range hunks {
+		expectedAt := aHunk.OldStart - 1 + offset
+		if len(aHunk.OldLines) == 0 {
+			// pure addition: OldStart is the line after which the new lines go
+			expectedAt = aHunk.OldStart + offset
+		}
+		at := findHunkPosition(lines, expectedAt, aHunk.OldLines)
+		if at < 0 {
+			return "", fmt.Errorf("Hunk #%d doesn't apply", idx+1)
+		}
+
+		updated := append([]string{}, lines[:at]...)
+		updated = append(updated, aHunk.NewLines...)
+		updated = append(updated, lines[at+len(aHunk.OldLines):]...)
+
+		if at+len(aHunk.OldLines) == len(lines) {
+			// the hunk touches the end of the file
+			if aHunk.NewNoEOL {
+				hasTrailingNewline = false
+			} else if len(aHunk.NewLines) > 0 {
+				hasTrailingNewline = true
+			}
+		}
+
+		offset += len(aHunk.NewLines) - len(aHunk.OldLines)
+		lines = updated
+	}
+
+	return joinLines(lines, hasTrailingNewline), nil
+}
+
+// applyUnifiedDiff ...
+//  applies a (single file) unified diff on the content.
+// If the patch is already applied (it can be reverse applied)
+//  the content is returned as-is, so applying the same patch
+//  multiple times is safe.
+func applyUnifiedDiff(content, patchContent string) (string, error) {
+	hunks, err := parseUnifiedDiff(patchContent)
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse patch, error: %s", err)
+	}
+
+	patched, applyErr := applyHunks(content, hunks)
+	if applyErr == nil {
+		return patched, nil
+	}
+
+	reversedHunks := []patchHunkModel{}
+	for _, aHunk := range hunks {
+		reversedHunks = append(reversedHunks, aHunk.reversed())
+	}
+	if _, err := applyHunks(content, reversedHunks); err == nil {
+		// already applied
+		return content, nil
+	}
+
+	return "", applyErr
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyUnifiedDiff(t *testing.T) {
+	original := `line 1
+line 2
+line 3
+line 4
+line 5
+`
+	patch := `--- a/file.txt
++++ b/file.txt
+@@ -1,3 +1,3 @@
+ line 1
+-line 2
++line two
+ line 3
+@@ -5,1 +5,2 @@
+ line 5
++line 6
+`
+	expected := `line 1
+line two
+line 3
+line 4
+line 5
+line 6
+`
+
+	t.Log("Apply")
+	patched, err := applyUnifiedDiff(original, patch)
+	require.NoError(t, err)
+	require.Equal(t, expected, patched)
+
+	t.Log("Already applied - content is returned as-is")
+	patched, err = applyUnifiedDiff(expected, patch)
+	require.NoError(t, err)
+	require.Equal(t, expected, patched)
+
+	t.Log("Apply with offset - lines added before the hunks")
+	patched, err = applyUnifiedDiff("line 0\n"+original, patch)
+	require.NoError(t, err)
+	require.Equal(t, "line 0\n"+expected, patched)
+
+	t.Log("Doesn't apply")
+	_, err = applyUnifiedDiff("something else\n", patch)
+	require.EqualError(t, err, "Hunk #1 doesn't apply")
+
+	t.Log("Invalid patch")
+	_, err = applyUnifiedDiff(original, "not a patch")
+	require.EqualError(t, err, "Failed to parse patch, error: No hunks found in patch")
+}
+
+func Test_applyUnifiedDiff_noNewlineAtEOF(t *testing.T) {
+	patch := `@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+`
+	patched, err := applyUnifiedDiff("a\nb", patch)
+	require.NoError(t, err)
+	require.Equal(t, "a\nc\n", patched)
+
+	t.Log("Pure addition into an empty file")
+	patched, err = applyUnifiedDiff("", `@@ -0,0 +1,2 @@
++first
++second
+`)
+	require.NoError(t, err)
+	require.Equal(t, "first\nsecond\n", patched)
+}
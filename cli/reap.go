@@ -67,7 +67,7 @@ func reapPlants(plantIDs []string, gardenMap config.GardenMapModel, cmdParams Re
 
 func printPlantsReadyForReap(plantsToReapIDs []string) {
 	fmt.Println()
-	log.Infof(colorstring.Blue("Plants ready to reap:"))
+	log.Infoln(colorstring.Blue("Plants ready to reap:"))
 	for _, aPlantID := range plantsToReapIDs {
 		log.Printf(" * %s", colorstring.Green(aPlantID))
 	}
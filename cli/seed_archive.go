@@ -0,0 +1,422 @@
+package cli
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// archiveSeedCacheDirPath ...
+//  archives are extracted into a content addressed cache dir,
+//  based on the archive's SHA256 checksum
+func archiveSeedCacheDirPath(gardenDirAbsPth, checksum string) string {
+	return filepath.Join(gardenDirAbsPth, cacheDirName, "seeds", "archive", checksum)
+}
+
+func isHTTPURL(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+// seedArchiveDownloadTimeout : the max time of a seed archive's download,
+//  including reading the response body
+var seedArchiveDownloadTimeout = 5 * time.Minute
+
+func downloadFile(url, targetPth string) error {
+	client := &http.Client{Timeout: seedArchiveDownloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Warnf("Failed to close response body, error: %s", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected response status: %s", resp.Status)
+	}
+
+	outFile, err := os.Create(targetPth)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(outFile, resp.Body); err != nil {
+		_ = outFile.Close()
+		return err
+	}
+	return outFile.Close()
+}
+
+func fileSHA256(pth string) (string, error) {
+	file, err := os.Open(pth)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close file (path:%s), error: %s", pth, err)
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func stringSHA256(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+// archiveEntryTargetPath ...
+//  returns the path where the archive entry should be extracted,
+//  or an error if the entry would be extracted outside of the target dir
+func archiveEntryTargetPath(targetDirPth, entryName string) (string, error) {
+	if filepath.IsAbs(entryName) || strings.HasPrefix(entryName, "/") || strings.HasPrefix(entryName, `\`) {
+		return "", fmt.Errorf("Absolute path in archive: %s", entryName)
+	}
+	cleanName := filepath.Clean(filepath.FromSlash(entryName))
+	if cleanName == ".." || strings.HasPrefix(cleanName, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Path outside of the archive's root: %s", entryName)
+	}
+	for _, aComponent := range strings.Split(filepath.ToSlash(entryName), "/") {
+		if aComponent == ".." {
+			return "", fmt.Errorf("Path with '..' in archive: %s", entryName)
+		}
+	}
+	targetPth := filepath.Join(targetDirPth, cleanName)
+
+	// an entry can't be extracted through a symlink of the archive,
+	//  a symlink to a parent dir would allow a later symlink to escape
+	for parentPth := filepath.Dir(targetPth); parentPth != targetDirPth && strings.HasPrefix(parentPth, targetDirPth); parentPth = filepath.Dir(parentPth) {
+		if fileInfo, err := os.Lstat(parentPth); err == nil && fileInfo.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("Path inside a symlinked directory in archive: %s", entryName)
+		}
+	}
+	return targetPth, nil
+}
+
+// resolveArchiveLinkTarget ...
+//  resolves the symlink's target the way the OS would, component by component,
+//  following the symlinks which are already extracted. The components which
+//  don't exist yet are appended as they are, those can't contain '..'.
+func resolveArchiveLinkTarget(linkPth, linkTarget string) (string, error) {
+	resolvedPth, err := filepath.EvalSymlinks(filepath.Dir(linkPth))
+	if err != nil {
+		return "", err
+	}
+	components := strings.Split(filepath.ToSlash(linkTarget), "/")
+	for idx, aComponent := range components {
+		if aComponent == "" || aComponent == "." {
+			continue
+		}
+		// resolvedPth has no symlinks, '..' is its parent dir
+		nextPth := resolvedPth + string(filepath.Separator) + aComponent
+		if _, err := os.Lstat(nextPth); os.IsNotExist(err) {
+			for _, aMissingComponent := range components[idx:] {
+				if aMissingComponent == ".." {
+					return "", fmt.Errorf("'..' after a path which doesn't exist")
+				}
+			}
+			return filepath.Join(resolvedPth, filepath.FromSlash(strings.Join(components[idx:], "/"))), nil
+		} else if err != nil {
+			return "", err
+		}
+		if resolvedPth, err = filepath.EvalSymlinks(nextPth); err != nil {
+			return "", err
+		}
+	}
+	return resolvedPth, nil
+}
+
+// checkArchiveLinkTarget ...
+//  symlinks can only point inside the target dir, the target is
+//  resolved through the symlinks which are already extracted
+func checkArchiveLinkTarget(targetDirPth, linkPth, linkTarget string) error {
+	if filepath.IsAbs(linkTarget) {
+		return fmt.Errorf("Symlink with absolute target in archive: %s -> %s", linkPth, linkTarget)
+	}
+	isInside := func(rootPth, pth string) bool {
+		relPth, err := filepath.Rel(rootPth, pth)
+		return err == nil && relPth != ".." && !strings.HasPrefix(relPth, ".."+string(filepath.Separator))
+	}
+	if !isInside(targetDirPth, filepath.Join(filepath.Dir(linkPth), filepath.FromSlash(linkTarget))) {
+		return fmt.Errorf("Symlink pointing outside of the archive's root: %s -> %s", linkPth, linkTarget)
+	}
+
+	resolvedTargetDirPth, err := filepath.EvalSymlinks(targetDirPth)
+	if err != nil {
+		return err
+	}
+	resolvedPth, err := resolveArchiveLinkTarget(linkPth, linkTarget)
+	if err != nil {
+		return fmt.Errorf("Symlink pointing outside of the archive's root: %s -> %s, error: %s", linkPth, linkTarget, err)
+	}
+	if !isInside(resolvedTargetDirPth, resolvedPth) {
+		return fmt.Errorf("Symlink pointing outside of the archive's root: %s -> %s", linkPth, linkTarget)
+	}
+	return nil
+}
+
+// writeArchiveFile ...
+//  an existing entry (e.g. a symlink of the archive) is never
+//  overwritten or followed
+func writeArchiveFile(targetPth string, reader io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(targetPth), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(targetPth); err == nil {
+		return fmt.Errorf("Path already exists in archive: %s", targetPth)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	outFile, err := os.OpenFile(targetPth, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(outFile, reader); err != nil {
+		_ = outFile.Close()
+		return err
+	}
+	return outFile.Close()
+}
+
+func writeArchiveSymlink(targetDirPth, targetPth, linkTarget string) error {
+	if err := os.MkdirAll(filepath.Dir(targetPth), 0755); err != nil {
+		return err
+	}
+	if err := checkArchiveLinkTarget(targetDirPth, targetPth, linkTarget); err != nil {
+		return err
+	}
+	return os.Symlink(linkTarget, targetPth)
+}
+
+func extractTarGz(archivePth, targetDirPth string) error {
+	file, err := os.Open(archivePth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close file (path:%s), error: %s", archivePth, err)
+		}
+	}()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		targetPth, err := archiveEntryTargetPath(targetDirPth, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(targetPth, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := writeArchiveFile(targetPth, tarReader, header.FileInfo().Mode()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := writeArchiveSymlink(targetDirPth, targetPth, header.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			linkedPth, err := archiveEntryTargetPath(targetDirPth, header.Linkname)
+			if err != nil {
+				return err
+			}
+			// a hard link of a symlink would be a symlink at an other place,
+			//  with a relative target which wasn't checked there
+			if fileInfo, err := os.Lstat(linkedPth); err != nil {
+				return err
+			} else if !fileInfo.Mode().IsRegular() {
+				return fmt.Errorf("Hard link to a non regular file in archive: %s -> %s", header.Name, header.Linkname)
+			}
+			if err := os.Link(linkedPth, targetPth); err != nil {
+				return err
+			}
+		default:
+			log.Warnf("Unsupported entry type (%c) in archive, skipping: %s", header.Typeflag, header.Name)
+		}
+	}
+}
+
+func extractZip(archivePth, targetDirPth string) error {
+	zipReader, err := zip.OpenReader(archivePth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := zipReader.Close(); err != nil {
+			log.Warnf("Failed to close archive (path:%s), error: %s", archivePth, err)
+		}
+	}()
+
+	for _, aFile := range zipReader.File {
+		targetPth, err := archiveEntryTargetPath(targetDirPth, aFile.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := aFile.Mode()
+		if mode.IsDir() {
+			if err := os.MkdirAll(targetPth, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		reader, err := aFile.Open()
+		if err != nil {
+			return err
+		}
+		if mode&os.ModeSymlink != 0 {
+			linkTarget, err := ioutil.ReadAll(reader)
+			if err == nil {
+				err = writeArchiveSymlink(targetDirPth, targetPth, string(linkTarget))
+			}
+			if closeErr := reader.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = writeArchiveFile(targetPth, reader, mode)
+		if closeErr := reader.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractArchive(archivePth, targetDirPth string) error {
+	if strings.HasSuffix(strings.ToLower(archivePth), ".zip") {
+		return extractZip(archivePth, targetDirPth)
+	}
+	return extractTarGz(archivePth, targetDirPth)
+}
+
+// fetchArchiveSeed ...
+//  downloads (or reads from a local path, relative to the garden dir) the
+//  seed's archive, verifies its checksum and extracts it into the cache dir.
+//  If the archive with the same checksum is already extracted the cached
+//  version is used.
+func fetchArchiveSeed(gardenDirAbsPth string, seedRef config.SeedRefModel, expectedSHA256 string, logger *log.Logger) (resolvedSeedModel, error) {
+	if expectedSHA256 == "" {
+		return resolvedSeedModel{}, fmt.Errorf("No seed_sha256 specified for archive seed: %s", seedRef.URL)
+	}
+	expectedSHA256 = strings.ToLower(expectedSHA256)
+	cacheDirPth := archiveSeedCacheDirPath(gardenDirAbsPth, expectedSHA256)
+
+	isCached, err := pathutil.IsDirExists(cacheDirPth)
+	if err != nil {
+		return resolvedSeedModel{}, err
+	}
+	if !isCached {
+		if err := os.MkdirAll(filepath.Dir(cacheDirPth), 0755); err != nil {
+			return resolvedSeedModel{}, err
+		}
+		tmpDirPth, err := ioutil.TempDir(filepath.Dir(cacheDirPth), ".tmp-")
+		if err != nil {
+			return resolvedSeedModel{}, err
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpDirPth); err != nil {
+				logger.Warnf("Failed to remove temp dir (path:%s), error: %s", tmpDirPth, err)
+			}
+		}()
+
+		archivePth := seedRef.URL
+		if isHTTPURL(seedRef.URL) {
+			logger.Infof(" -> Downloading seed archive: %s", seedRef.URL)
+			archivePth = filepath.Join(tmpDirPth, "seed-archive"+archiveExtension(seedRef.URL))
+			if err := downloadFile(seedRef.URL, archivePth); err != nil {
+				return resolvedSeedModel{}, fmt.Errorf("Failed to download seed archive (%s), error: %s", seedRef.URL, err)
+			}
+		} else if !filepath.IsAbs(archivePth) && !strings.HasPrefix(archivePth, "~/") {
+			archivePth = filepath.Join(gardenDirAbsPth, archivePth)
+		}
+		archivePth, err = pathutil.AbsPath(archivePth)
+		if err != nil {
+			return resolvedSeedModel{}, err
+		}
+
+		checksum, err := fileSHA256(archivePth)
+		if err != nil {
+			return resolvedSeedModel{}, fmt.Errorf("Failed to calculate checksum of seed archive (%s), error: %s", archivePth, err)
+		}
+		if checksum != expectedSHA256 {
+			return resolvedSeedModel{}, fmt.Errorf("Checksum mismatch for seed archive (%s): expected %s, got %s", seedRef.URL, expectedSHA256, checksum)
+		}
+
+		logger.Infof(" -> Extracting seed archive: %s", seedRef.URL)
+		extractDirPth := filepath.Join(tmpDirPth, "content")
+		if err := os.MkdirAll(extractDirPth, 0755); err != nil {
+			return resolvedSeedModel{}, err
+		}
+		if err := extractArchive(archivePth, extractDirPth); err != nil {
+			return resolvedSeedModel{}, fmt.Errorf("Failed to extract seed archive (%s), error: %s", seedRef.URL, err)
+		}
+		if err := os.Rename(extractDirPth, cacheDirPth); err != nil {
+			return resolvedSeedModel{}, fmt.Errorf("Failed to move extracted seed into the cache, error: %s", err)
+		}
+	}
+
+	seedDirPth := filepath.Join(cacheDirPth, filepath.FromSlash(seedRef.SubDir))
+	isExist, err := pathutil.IsDirExists(seedDirPth)
+	if err != nil {
+		return resolvedSeedModel{}, err
+	}
+	if !isExist {
+		return resolvedSeedModel{}, fmt.Errorf("No directory found in seed archive (%s) at path: %s", seedRef.URL, seedRef.SubDir)
+	}
+
+	return resolvedSeedModel{
+		Ref:      seedRef,
+		Dir:      seedDirPth,
+		Revision: expectedSHA256,
+	}, nil
+}
+
+func archiveExtension(url string) string {
+	if strings.HasSuffix(strings.ToLower(url), ".zip") {
+		return ".zip"
+	}
+	return ".tar.gz"
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testArchiveEntry ...
+//  a file (Content), a directory (IsDir) or a symlink (LinkTarget)
+type testArchiveEntry struct {
+	Name       string
+	Content    string
+	IsDir      bool
+	LinkTarget string
+	Mode       int64
+}
+
+func writeTestTarGz(t *testing.T, pth string, entries []testArchiveEntry) {
+	file, err := os.Create(pth)
+	require.NoError(t, err)
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, anEntry := range entries {
+		header := &tar.Header{Name: anEntry.Name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(anEntry.Content))}
+		if anEntry.Mode != 0 {
+			header.Mode = anEntry.Mode
+		}
+		if anEntry.IsDir {
+			header.Typeflag = tar.TypeDir
+			header.Mode = 0755
+			header.Size = 0
+		} else if anEntry.LinkTarget != "" {
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = anEntry.LinkTarget
+			header.Size = 0
+		}
+		require.NoError(t, tarWriter.WriteHeader(header))
+		if header.Typeflag == tar.TypeReg {
+			_, err := tarWriter.Write([]byte(anEntry.Content))
+			require.NoError(t, err)
+		}
+	}
+
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	require.NoError(t, file.Close())
+}
+
+func writeTestZip(t *testing.T, pth string, entries []testArchiveEntry) {
+	file, err := os.Create(pth)
+	require.NoError(t, err)
+	zipWriter := zip.NewWriter(file)
+
+	for _, anEntry := range entries {
+		header := &zip.FileHeader{Name: anEntry.Name}
+		content := anEntry.Content
+		header.SetMode(0644)
+		if anEntry.LinkTarget != "" {
+			header.SetMode(os.ModeSymlink | 0777)
+			content = anEntry.LinkTarget
+		}
+		writer, err := zipWriter.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = writer.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zipWriter.Close())
+	require.NoError(t, file.Close())
+}
+
+func Test_resolveSeed_archive(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	require.NoError(t, os.MkdirAll(filepath.Join(gardenDirPth, "dist"), 0777))
+
+	archivePth := filepath.Join(gardenDirPth, "dist", "seeds.tar.gz")
+	writeTestTarGz(t, archivePth, []testArchiveEntry{
+		{Name: "apples/", IsDir: true},
+		{Name: "apples/file.txt", Content: "apple\n"},
+		{Name: "apples/run.sh", Content: "#!/bin/bash\n", Mode: 0755},
+		{Name: "apples/link.txt", LinkTarget: "file.txt"},
+	})
+	checksum, err := fileSHA256(archivePth)
+	require.NoError(t, err)
+
+	t.Log("Local archive, relative to the garden dir, with subdir")
+	resolvedSeed, err := resolveSeed(gardenDirPth, "dist/seeds.tar.gz//apples", checksum, "", log.StandardLogger())
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(archiveSeedCacheDirPath(gardenDirPth, checksum), "apples"), resolvedSeed.Dir)
+	require.Equal(t, checksum, resolvedSeed.Revision)
+	testFileContent(t, filepath.Join(resolvedSeed.Dir, "file.txt"), "apple\n")
+	perms, err := fileutil.GetFilePermissions(filepath.Join(resolvedSeed.Dir, "run.sh"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), perms)
+	linkTarget, err := os.Readlink(filepath.Join(resolvedSeed.Dir, "link.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "file.txt", linkTarget)
+
+	t.Log("Already extracted - the cache is used")
+	require.NoError(t, os.Remove(archivePth))
+	resolvedSeed, err = resolveSeed(gardenDirPth, "dist/seeds.tar.gz//apples", checksum, "", log.StandardLogger())
+	require.NoError(t, err)
+	testFileContent(t, filepath.Join(resolvedSeed.Dir, "file.txt"), "apple\n")
+
+	t.Log("No checksum")
+	_, err = resolveSeed(gardenDirPth, "dist/seeds.tar.gz", "", "", log.StandardLogger())
+	require.EqualError(t, err, "No seed_sha256 specified for archive seed: dist/seeds.tar.gz")
+
+	t.Log("Checksum mismatch")
+	otherArchivePth := filepath.Join(tmpDir, "other.tar.gz")
+	writeTestTarGz(t, otherArchivePth, []testArchiveEntry{{Name: "file.txt", Content: "other\n"}})
+	otherChecksum, err := fileSHA256(otherArchivePth)
+	require.NoError(t, err)
+	wrongChecksum := "0000000000000000000000000000000000000000000000000000000000000000"
+	_, err = resolveSeed(gardenDirPth, otherArchivePth, wrongChecksum, "", log.StandardLogger())
+	require.EqualError(t, err, "Checksum mismatch for seed archive ("+otherArchivePth+"): expected "+wrongChecksum+", got "+otherChecksum)
+}
+
+func Test_resolveSeed_archiveHTTP(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	serveDirPth := filepath.Join(tmpDir, "serve")
+	require.NoError(t, os.MkdirAll(serveDirPth, 0777))
+
+	archivePth := filepath.Join(serveDirPth, "seed.zip")
+	writeTestZip(t, archivePth, []testArchiveEntry{
+		{Name: "file.txt", Content: "zipped\n"},
+		{Name: "sub/link.txt", LinkTarget: "../file.txt"},
+	})
+	checksum, err := fileSHA256(archivePth)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.FileServer(http.Dir(serveDirPth)))
+	defer server.Close()
+
+	resolvedSeed, err := resolveSeed(gardenDirPth, server.URL+"/seed.zip", checksum, "", log.StandardLogger())
+	require.NoError(t, err)
+	testFileContent(t, filepath.Join(resolvedSeed.Dir, "file.txt"), "zipped\n")
+	testFileContent(t, filepath.Join(resolvedSeed.Dir, "sub", "link.txt"), "zipped\n")
+
+	t.Log("Not found - the response is not written into the file")
+	targetPth := filepath.Join(tmpDir, "not-found.zip")
+	require.Error(t, downloadFile(server.URL+"/not-found.zip", targetPth))
+	isExist, err := pathutil.IsPathExists(targetPth)
+	require.NoError(t, err)
+	require.False(t, isExist)
+	_, err = resolveSeed(gardenDirPth, server.URL+"/not-found.zip", checksum+"0", "", log.StandardLogger())
+	require.Error(t, err)
+
+	t.Log("Stalled server - the download times out")
+	stalledServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer stalledServer.Close()
+	origTimeout := seedArchiveDownloadTimeout
+	seedArchiveDownloadTimeout = 50 * time.Millisecond
+	defer func() {
+		seedArchiveDownloadTimeout = origTimeout
+	}()
+	err = downloadFile(stalledServer.URL+"/seed.zip", filepath.Join(tmpDir, "stalled.zip"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Timeout")
+}
+
+func Test_extractArchive_unsafeEntries(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	testCases := map[string][]testArchiveEntry{
+		"Absolute path in archive: /etc/evil": {
+			{Name: "/etc/evil", Content: "evil"},
+		},
+		"Path outside of the archive's root: a/../../evil": {
+			{Name: "a/../../evil", Content: "evil"},
+		},
+		"Path with '..' in archive: a/../b": {
+			{Name: "a/../b", Content: "evil"},
+		},
+		"Symlink pointing outside of the archive's root": {
+			{Name: "link", LinkTarget: "../outside"},
+		},
+		"Symlink with absolute target in archive": {
+			{Name: "link", LinkTarget: "/etc/passwd"},
+		},
+		"Path inside a symlinked directory in archive: dir/escape": {
+			{Name: "dir", LinkTarget: "."},
+			{Name: "dir/escape", LinkTarget: "../outside"},
+		},
+		"/e -> d/..": {
+			{Name: "d", LinkTarget: "."},
+			{Name: "e", LinkTarget: "d/.."},
+			{Name: "g", LinkTarget: "e/victim.txt"},
+			{Name: "g", Content: "evil"},
+		},
+		"Path already exists in archive": {
+			{Name: "link", LinkTarget: "README.md"},
+			{Name: "link", Content: "evil"},
+		},
+	}
+
+	idx := 0
+	for expectedErr, entries := range testCases {
+		idx++
+		for _, anExt := range []string{".tar.gz", ".zip"} {
+			archivePth := filepath.Join(tmpDir, "unsafe"+anExt)
+			if anExt == ".zip" {
+				writeTestZip(t, archivePth, entries)
+			} else {
+				writeTestTarGz(t, archivePth, entries)
+			}
+			targetDirPth := filepath.Join(tmpDir, "target", anExt, string(rune('a'+idx)))
+			require.NoError(t, os.MkdirAll(targetDirPth, 0777))
+
+			err := extractArchive(archivePth, targetDirPth)
+			require.Error(t, err, expectedErr)
+			require.Contains(t, err.Error(), expectedErr)
+		}
+	}
+
+	for _, aPth := range []string{
+		filepath.Join(tmpDir, "outside"),
+		filepath.Join(tmpDir, "target", ".tar.gz", "victim.txt"),
+		filepath.Join(tmpDir, "target", ".zip", "victim.txt"),
+	} {
+		isExist, err := pathutil.IsPathExists(aPth)
+		require.NoError(t, err)
+		require.False(t, isExist, aPth)
+	}
+}
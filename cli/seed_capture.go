@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/codegangsta/cli"
+)
+
+// defaultCaptureIgnorePatterns : never captured into a seed
+var defaultCaptureIgnorePatterns = []string{".git"}
+
+// captureVarModel ...
+//  a literal of the captured directory, which is replaced with a var
+type captureVarModel struct {
+	Literal string
+	VarName string
+}
+
+// captureVarsByLiteralLength : longer literals first
+type captureVarsByLiteralLength []captureVarModel
+
+func (captureVars captureVarsByLiteralLength) Len() int      { return len(captureVars) }
+func (captureVars captureVarsByLiteralLength) Swap(i, j int) { captureVars[i], captureVars[j] = captureVars[j], captureVars[i] }
+func (captureVars captureVarsByLiteralLength) Less(i, j int) bool {
+	return len(captureVars[i].Literal) > len(captureVars[j].Literal)
+}
+
+// captureVarsByName ...
+type captureVarsByName []captureVarModel
+
+func (captureVars captureVarsByName) Len() int      { return len(captureVars) }
+func (captureVars captureVarsByName) Swap(i, j int) { captureVars[i], captureVars[j] = captureVars[j], captureVars[i] }
+func (captureVars captureVarsByName) Less(i, j int) bool {
+	return captureVars[i].VarName < captureVars[j].VarName
+}
+
+// seedCaptureResultModel ...
+type seedCaptureResultModel struct {
+	SeedDir string
+	// Vars : the vars the new seed expects (the ones which were found
+	//  in the captured files), sorted by name
+	Vars []captureVarModel
+	// TemplateFiles : the seed relative paths of the created template files
+	TemplateFiles []string
+}
+
+// parseCaptureVars ...
+//  parses `Literal=VarName` items; the last = separates the var name,
+//  so the literal can include = characters
+func parseCaptureVars(items []string) ([]captureVarModel, error) {
+	captureVars := []captureVarModel{}
+	for _, anItem := range items {
+		idx := strings.LastIndex(anItem, "=")
+		if idx < 0 {
+			return []captureVarModel{}, fmt.Errorf("Invalid var mapping (%s), should be in the format: Literal=VarName", anItem)
+		}
+		captureVar := captureVarModel{Literal: anItem[:idx], VarName: anItem[idx+1:]}
+		if captureVar.Literal == "" || captureVar.VarName == "" {
+			return []captureVarModel{}, fmt.Errorf("Invalid var mapping (%s), both the literal and the var name are required", anItem)
+		}
+		captureVars = append(captureVars, captureVar)
+	}
+	return captureVars, nil
+}
+
+// isCaptureIgnored ...
+//  a pattern is matched against the (slash separated) relative path
+//  and against the file's name, so `*.log` ignores log files in every dir
+func isCaptureIgnored(relPth string, ignorePatterns []string) bool {
+	for _, aPattern := range ignorePatterns {
+		if isMatch, err := path.Match(aPattern, relPth); err == nil && isMatch {
+			return true
+		}
+		if isMatch, err := path.Match(aPattern, path.Base(relPth)); err == nil && isMatch {
+			return true
+		}
+	}
+	return false
+}
+
+// captureFileContent ...
+//  if the content includes any of the literals (or isTemplate is true)
+//  the template delimiters of the content are escaped and the literals
+//  are replaced with var calls. Returns the new content, the vars which
+//  were found, and whether the content should be written as a template.
+func captureFileContent(content string, captureVars []captureVarModel, isTemplate bool) (string, []string, bool) {
+	foundVarNames := []string{}
+	for _, aCaptureVar := range captureVars {
+		if strings.Contains(content, aCaptureVar.Literal) {
+			foundVarNames = append(foundVarNames, aCaptureVar.VarName)
+		}
+	}
+	if len(foundVarNames) == 0 && !isTemplate {
+		return content, foundVarNames, false
+	}
+
+	// placeholders first, so a var call is never matched by an other literal
+	//  and its delimiters are not escaped
+	placeholders := []string{}
+	for idx, aCaptureVar := range captureVars {
+		placeholder := fmt.Sprintf("\x00garden-capture-%d\x00", idx)
+		placeholders = append(placeholders, placeholder)
+		content = strings.Replace(content, aCaptureVar.Literal, placeholder, -1)
+	}
+	content = escapeTemplateDelimiters(content)
+	for idx, aCaptureVar := range captureVars {
+		content = strings.Replace(content, placeholders[idx], fmt.Sprintf("{{ var %q }}", aCaptureVar.VarName), -1)
+	}
+	return content, foundVarNames, true
+}
+
+// captureRelPath ...
+//  replaces the literals in the names of the (slash separated) relative
+//  path's components with var calls, which are evaluated when the seed is
+//  grown. The calls use raw strings, names can't include " on every OS.
+//  Returns the new relative path and the vars which were found.
+func captureRelPath(relPth string, captureVars []captureVarModel) (string, []string) {
+	foundVarNames := []string{}
+	isFoundVarName := map[string]bool{}
+	components := strings.Split(relPth, "/")
+	for idx, aComponent := range components {
+		placeholders := map[string]string{}
+		for varIdx, aCaptureVar := range captureVars {
+			if !strings.Contains(aComponent, aCaptureVar.Literal) {
+				continue
+			}
+			placeholder := fmt.Sprintf("\x00garden-capture-%d\x00", varIdx)
+			placeholders[placeholder] = fmt.Sprintf("{{ var `%s` }}", aCaptureVar.VarName)
+			aComponent = strings.Replace(aComponent, aCaptureVar.Literal, placeholder, -1)
+			if !isFoundVarName[aCaptureVar.VarName] {
+				isFoundVarName[aCaptureVar.VarName] = true
+				foundVarNames = append(foundVarNames, aCaptureVar.VarName)
+			}
+		}
+		if len(placeholders) == 0 && !strings.Contains(aComponent, "{{") {
+			continue
+		}
+
+		aComponent = strings.Replace(aComponent, "{{", "{{`{{`}}", -1)
+		for placeholder, varCall := range placeholders {
+			aComponent = strings.Replace(aComponent, placeholder, varCall, -1)
+		}
+		components[idx] = aComponent
+	}
+	return strings.Join(components, "/"), foundVarNames
+}
+
+// validateCaptureSeedName ...
+//  the seed is created in the garden's seeds dir, its name can't be a path
+func validateCaptureSeedName(seedName string) error {
+	if seedName == "" || seedName == "." || seedName == ".." ||
+		strings.ContainsAny(seedName, `/\`) || filepath.VolumeName(seedName) != "" {
+		return fmt.Errorf("Invalid seed name (%s), it has to be a directory name, without path separators", seedName)
+	}
+	return nil
+}
+
+// escapeTemplateDelimiters ...
+//  escapes the {{ in the content, so that it's not evaluated as a template action
+func escapeTemplateDelimiters(content string) string {
+	return strings.Replace(content, "{{", `{{"{{"}}`, -1)
+}
+
+// captureSeed ...
+//  copies the directory into a new seed of the garden dir, skipping the
+//  ignored paths. Files which include any of the literals (and files
+//  which already are templates) are written as .template files, with the
+//  literals replaced by var calls. The literals in the file and directory
+//  names are replaced by var calls too.
+func captureSeed(gardenDirAbsPth, srcDirPth, seedName string, captureVars []captureVarModel, ignorePatterns []string) (seedCaptureResultModel, error) {
+	if err := validateCaptureSeedName(seedName); err != nil {
+		return seedCaptureResultModel{}, err
+	}
+	seedDirPth := filepath.Join(gardenDirAbsPth, "seeds", seedName)
+	if isExist, err := pathutil.IsPathExists(seedDirPth); err != nil {
+		return seedCaptureResultModel{}, err
+	} else if isExist {
+		return seedCaptureResultModel{}, fmt.Errorf("Seed already exists at path: %s", seedDirPth)
+	}
+	srcDirPth, err := pathutil.AbsPath(srcDirPth)
+	if err != nil {
+		return seedCaptureResultModel{}, err
+	}
+	if isExist, err := pathutil.IsDirExists(srcDirPth); err != nil {
+		return seedCaptureResultModel{}, err
+	} else if !isExist {
+		return seedCaptureResultModel{}, fmt.Errorf("Directory not found at path: %s", srcDirPth)
+	}
+
+	// longer literals first, so a literal which includes an other one wins
+	sortedCaptureVars := append([]captureVarModel{}, captureVars...)
+	sort.Stable(captureVarsByLiteralLength(sortedCaptureVars))
+	ignorePatterns = append(append([]string{}, defaultCaptureIgnorePatterns...), ignorePatterns...)
+
+	result := seedCaptureResultModel{SeedDir: seedDirPth, TemplateFiles: []string{}}
+	usedVarNames := map[string]bool{}
+	err = filepath.Walk(srcDirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPth, err := filepath.Rel(srcDirPth, pth)
+		if err != nil {
+			return err
+		}
+		if relPth == "." {
+			return os.MkdirAll(seedDirPth, fileInfo.Mode().Perm())
+		}
+		slashRelPth := filepath.ToSlash(relPth)
+		if isCaptureIgnored(slashRelPth, ignorePatterns) {
+			log.Debugf("-> Ignored: %s", slashRelPth)
+			if fileInfo.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		capturedRelPth, foundVarNames := captureRelPath(slashRelPth, sortedCaptureVars)
+		for _, aVarName := range foundVarNames {
+			usedVarNames[aVarName] = true
+		}
+		targetPth := filepath.Join(seedDirPth, filepath.FromSlash(capturedRelPth))
+
+		if fileInfo.IsDir() {
+			return os.MkdirAll(targetPth, fileInfo.Mode().Perm())
+		}
+		if fileInfo.Mode()&os.ModeSymlink != 0 {
+			linkTarget, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(linkTarget, targetPth)
+		}
+		if !fileInfo.Mode().IsRegular() {
+			log.Warnf("Not a regular file, skipping: %s", slashRelPth)
+			return nil
+		}
+
+		content, err := fileutil.ReadStringFromFile(pth)
+		if err != nil {
+			return err
+		}
+		// binary files are copied as they are
+		if !strings.Contains(content, "\x00") {
+			// an existing template file has to be escaped as well,
+			//  to get the same .template file in the plant
+			capturedContent, foundVarNames, isTemplate := captureFileContent(content, sortedCaptureVars, filepath.Ext(pth) == ".template")
+			if isTemplate {
+				for _, aVarName := range foundVarNames {
+					usedVarNames[aVarName] = true
+				}
+				result.TemplateFiles = append(result.TemplateFiles, capturedRelPth+".template")
+				content = capturedContent
+				targetPth += ".template"
+			}
+		}
+		return fileutil.WriteStringToFileWithPermission(targetPth, content, fileInfo.Mode().Perm())
+	})
+	if err != nil {
+		return seedCaptureResultModel{}, fmt.Errorf("Failed to capture directory (path:%s), error: %s", srcDirPth, err)
+	}
+
+	result.Vars = []captureVarModel{}
+	for _, aCaptureVar := range captureVars {
+		if usedVarNames[aCaptureVar.VarName] {
+			result.Vars = append(result.Vars, aCaptureVar)
+			delete(usedVarNames, aCaptureVar.VarName)
+		}
+	}
+	sort.Sort(captureVarsByName(result.Vars))
+	return result, nil
+}
+
+func seedCapture(c *cli.Context) {
+	log.Infoln("Seed capture")
+
+	if len(c.Args()) != 2 {
+		log.Fatalln("Usage: garden seed capture [--var Literal=VarName]... [--ignore pattern]... DIR SEED-NAME")
+	}
+	srcDirPth, seedName := c.Args()[0], c.Args()[1]
+
+	_, gardenDirAbsPth, err := config.LoadGardenMap("")
+	if err != nil {
+		log.Fatalf("Failed to load Garden Map: %s", err)
+	}
+	captureVars, err := parseCaptureVars(c.StringSlice(VarKey))
+	if err != nil {
+		log.Fatalf("Failed to parse vars: %s", err)
+	}
+
+	result, err := captureSeed(gardenDirAbsPth, srcDirPth, seedName, captureVars, c.StringSlice(IgnoreKey))
+	if err != nil {
+		log.Fatalf("Failed to capture seed: %s", err)
+	}
+
+	fmt.Println()
+	log.Infoln(colorstring.Green("Seed created:"), result.SeedDir)
+	log.Infoln("Template files:", result.TemplateFiles)
+	if len(result.Vars) == 0 {
+		log.Infoln("The seed doesn't expect any vars")
+		return
+	}
+	log.Infoln("The seed expects the following vars (with the captured values):")
+	fmt.Println("vars:")
+	for _, aCaptureVar := range result.Vars {
+		fmt.Printf("  %s: %q\n", aCaptureVar.VarName, aCaptureVar.Literal)
+	}
+}
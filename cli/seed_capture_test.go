@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseCaptureVars(t *testing.T) {
+	captureVars, err := parseCaptureVars([]string{"MyApp=AppName", "a=b=C"})
+	require.NoError(t, err)
+	require.Equal(t, []captureVarModel{
+		{Literal: "MyApp", VarName: "AppName"},
+		{Literal: "a=b", VarName: "C"},
+	}, captureVars)
+
+	_, err = parseCaptureVars([]string{"MyApp"})
+	require.Error(t, err)
+	_, err = parseCaptureVars([]string{"=AppName"})
+	require.Error(t, err)
+	_, err = parseCaptureVars([]string{"MyApp="})
+	require.Error(t, err)
+}
+
+func Test_captureFileContent(t *testing.T) {
+	captureVars := []captureVarModel{
+		{Literal: "MyAppTests", VarName: "TestsName"},
+		{Literal: "MyApp", VarName: "AppName"},
+	}
+
+	t.Log("No literal found")
+	content, foundVarNames, isTemplate := captureFileContent("nothing {{ here }}", captureVars, false)
+	require.Equal(t, "nothing {{ here }}", content)
+	require.Equal(t, []string{}, foundVarNames)
+	require.False(t, isTemplate)
+
+	t.Log("Literals and delimiters")
+	content, foundVarNames, isTemplate = captureFileContent("MyApp, MyAppTests {{ x }}", captureVars, false)
+	require.Equal(t, `{{ var "AppName" }}, {{ var "TestsName" }} {{"{{"}} x }}`, content)
+	require.Equal(t, []string{"TestsName", "AppName"}, foundVarNames)
+	require.True(t, isTemplate)
+
+	t.Log("Existing template")
+	content, _, isTemplate = captureFileContent("{{ .Foo }}", captureVars, true)
+	require.Equal(t, `{{"{{"}} .Foo }}`, content)
+	require.True(t, isTemplate)
+}
+
+func Test_captureRelPath(t *testing.T) {
+	captureVars := []captureVarModel{
+		{Literal: "MyAppTests", VarName: "TestsName"},
+		{Literal: "MyApp", VarName: "AppName"},
+	}
+
+	t.Log("No literal found")
+	relPth, foundVarNames := captureRelPath("docs/README.md", captureVars)
+	require.Equal(t, "docs/README.md", relPth)
+	require.Equal(t, []string{}, foundVarNames)
+
+	t.Log("Literals in dir and file names")
+	relPth, foundVarNames = captureRelPath("MyAppTests/MyApp.swift", captureVars)
+	require.Equal(t, "{{ var `TestsName` }}/{{ var `AppName` }}.swift", relPth)
+	require.Equal(t, []string{"TestsName", "AppName"}, foundVarNames)
+
+	t.Log("Delimiters in a name")
+	relPth, _ = captureRelPath("{{x}}/MyApp", captureVars)
+	require.Equal(t, "{{`{{`}}x}}/{{ var `AppName` }}", relPth)
+}
+
+func Test_validateCaptureSeedName(t *testing.T) {
+	require.NoError(t, validateCaptureSeedName("my-app"))
+	for _, aName := range []string{"", ".", "..", "../x", "a/b", `a\b`, "/abs"} {
+		require.Error(t, validateCaptureSeedName(aName), aName)
+	}
+}
+
+func Test_captureSeed(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	srcDirPth := filepath.Join(tmpDir, "my-app")
+
+	writeTestFile(t, filepath.Join(srcDirPth, "main.go"), "package MyApp\n\n// {{ not a template }}\n", 0644)
+	writeTestFile(t, filepath.Join(srcDirPth, "docs", "README.md"), "MyApp by MyOrg\n", 0644)
+	writeTestFile(t, filepath.Join(srcDirPth, "run.sh"), "#!/bin/bash\necho MyApp\n", 0755)
+	writeTestFile(t, filepath.Join(srcDirPth, "plain.txt"), "no vars\n", 0644)
+	writeTestFile(t, filepath.Join(srcDirPth, "config.yml.template"), "name: {{ .Name }}\n", 0644)
+	writeTestFile(t, filepath.Join(srcDirPth, "logo.bin"), "\x00MyApp\x00", 0644)
+	writeTestFile(t, filepath.Join(srcDirPth, "MyApp", "MyAppDelegate.swift"), "class Delegate {}\n", 0644)
+	writeTestFile(t, filepath.Join(srcDirPth, "{{raw}}.txt"), "raw\n", 0644)
+	require.NoError(t, os.Symlink("docs/README.md", filepath.Join(srcDirPth, "README.md")))
+	// ignored
+	writeTestFile(t, filepath.Join(srcDirPth, ".git", "HEAD"), "ref\n", 0644)
+	writeTestFile(t, filepath.Join(srcDirPth, "build", "MyApp"), "MyApp\n", 0644)
+	writeTestFile(t, filepath.Join(srcDirPth, "docs", "debug.log"), "MyApp\n", 0644)
+
+	captureVars := []captureVarModel{
+		{Literal: "MyOrg", VarName: "OrgName"},
+		{Literal: "MyApp", VarName: "AppName"},
+		{Literal: "NotFound", VarName: "Unused"},
+	}
+	result, err := captureSeed(gardenDirPth, srcDirPth, "my-app", captureVars, []string{"build", "*.log"})
+	require.NoError(t, err)
+	seedDirPth := filepath.Join(gardenDirPth, "seeds", "my-app")
+	require.Equal(t, seedDirPth, result.SeedDir)
+	require.Equal(t, []captureVarModel{
+		{Literal: "MyApp", VarName: "AppName"},
+		{Literal: "MyOrg", VarName: "OrgName"},
+	}, result.Vars)
+	require.Equal(t, []string{"config.yml.template.template", "docs/README.md.template", "main.go.template", "run.sh.template"}, result.TemplateFiles)
+	testFileContent(t, filepath.Join(seedDirPth, "docs", "README.md.template"), `{{ var "AppName" }} by {{ var "OrgName" }}`+"\n")
+	testFileContent(t, filepath.Join(seedDirPth, "plain.txt"), "no vars\n")
+	testFileContent(t, filepath.Join(seedDirPth, "{{ var `AppName` }}", "{{ var `AppName` }}Delegate.swift"), "class Delegate {}\n")
+	testFileContent(t, filepath.Join(seedDirPth, "{{`{{`}}raw}}.txt"), "raw\n")
+
+	t.Log("Growing the captured seed with the captured values results in the original directory")
+	for _, anIgnored := range []string{".git", "build", filepath.Join("docs", "debug.log")} {
+		require.NoError(t, os.RemoveAll(filepath.Join(srcDirPth, anIgnored)))
+	}
+	outDirPth := filepath.Join(tmpDir, "out")
+	require.NoError(t, os.MkdirAll(outDirPth, 0777))
+	gardenMap := config.GardenMapModel{}
+	inventory := GardenTemplateInventoryModel{Vars: map[string]string{"AppName": "MyApp", "OrgName": "MyOrg"}}
+	_, err = renderSeed(gardenDirPth, seedDirPth, outDirPth, filepath.Join(tmpDir, "no-plant"), inventory, gardenMap, log.StandardLogger())
+	require.NoError(t, err)
+	diffs, err := compareDirTrees(srcDirPth, outDirPth)
+	require.NoError(t, err)
+	require.Equal(t, []string{}, diffs, strings.Join(diffs, "\n"))
+
+	t.Log("Seed already exists")
+	_, err = captureSeed(gardenDirPth, srcDirPth, "my-app", captureVars, []string{})
+	require.Error(t, err)
+
+	t.Log("Invalid seed name")
+	_, err = captureSeed(gardenDirPth, srcDirPth, "../escaped", captureVars, []string{})
+	require.Error(t, err)
+	exists, err := pathutil.IsPathExists(filepath.Join(gardenDirPth, "escaped"))
+	require.NoError(t, err)
+	require.False(t, exists)
+}
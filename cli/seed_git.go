@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/cmdex"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+func runGitCommand(dir string, args ...string) (string, error) {
+	log.Debugf("-> git %v (in dir: %s)", args, dir)
+	output, err := cmdex.RunCommandInDirAndReturnCombinedStdoutAndStderr(dir, "git", args...)
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %s, output: %s", args[0], err, output)
+	}
+	return output, nil
+}
+
+// gitSeedCacheDirPath ...
+//  every git repository gets its own cache dir, based on its URL
+func gitSeedCacheDirPath(gardenDirAbsPth, url string) string {
+	urlHash := sha256.Sum256([]byte(url))
+	return filepath.Join(gardenDirAbsPth, cacheDirName, "seeds", "git", hex.EncodeToString(urlHash[:])[:16])
+}
+
+// fetchGitSeedRepository ...
+//  clones the repository into the cache dir, or if it's already cloned
+//  fetches the latest changes
+func fetchGitSeedRepository(url, repoDirPth string, logger *log.Logger) error {
+	isExist, err := pathutil.IsDirExists(filepath.Join(repoDirPth, ".git"))
+	if err != nil {
+		return err
+	}
+
+	if !isExist {
+		logger.Infof(" -> Cloning seed repository: %s", url)
+		if err := os.RemoveAll(repoDirPth); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(repoDirPth), 0755); err != nil {
+			return err
+		}
+		_, err := runGitCommand("", "clone", "--quiet", "--no-checkout", "--", url, repoDirPth)
+		return err
+	}
+
+	logger.Infof(" -> Fetching seed repository: %s", url)
+	_, err = runGitCommand(repoDirPth, "fetch", "--quiet", "--force", "--tags", "--prune", "origin")
+	return err
+}
+
+// resolveGitRevision ...
+//  resolves the ref (branch, tag or commit) to a commit hash,
+//  branches are resolved from the remote (origin), so they point to
+//  the latest fetched commit
+func resolveGitRevision(repoDirPth, ref string) (string, error) {
+	candidates := []string{"origin/HEAD"}
+	if ref != "" {
+		candidates = []string{"origin/" + ref, ref}
+	}
+
+	for _, aCandidate := range candidates {
+		revision, err := runGitCommand(repoDirPth, "rev-parse", "--verify", "--quiet", aCandidate+"^{commit}")
+		if err == nil && revision != "" {
+			return revision, nil
+		}
+	}
+	return "", fmt.Errorf("Can't find ref (%s) in the repository", ref)
+}
+
+// hasGitCommit ...
+//  whether the commit is already available in the (cached) repository
+func hasGitCommit(repoDirPth, revision string) bool {
+	if isExist, err := pathutil.IsDirExists(filepath.Join(repoDirPth, ".git")); err != nil || !isExist {
+		return false
+	}
+	_, err := runGitCommand(repoDirPth, "rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	return err == nil
+}
+
+// checkoutGitSeed ...
+//  fetches the seed's repository into the garden's cache dir
+//  and checks out the seed's ref.
+//  If lockedRevision is specified that commit is checked out instead of
+//  the ref, and the repository is only fetched if the commit is not yet cached.
+func checkoutGitSeed(gardenDirAbsPth string, seedRef config.SeedRefModel, lockedRevision string, logger *log.Logger) (resolvedSeedModel, error) {
+	repoDirPth := gitSeedCacheDirPath(gardenDirAbsPth, seedRef.URL)
+	if lockedRevision == "" || !hasGitCommit(repoDirPth, lockedRevision) {
+		if err := fetchGitSeedRepository(seedRef.URL, repoDirPth, logger); err != nil {
+			return resolvedSeedModel{}, fmt.Errorf("Failed to fetch seed repository (%s), error: %s", seedRef.URL, err)
+		}
+	}
+
+	revision := lockedRevision
+	if revision == "" {
+		resolvedRevision, err := resolveGitRevision(repoDirPth, seedRef.Ref)
+		if err != nil {
+			return resolvedSeedModel{}, fmt.Errorf("Failed to resolve ref of seed repository (%s), error: %s", seedRef.URL, err)
+		}
+		revision = resolvedRevision
+	} else if !hasGitCommit(repoDirPth, revision) {
+		return resolvedSeedModel{}, fmt.Errorf("Locked revision (%s) not found in seed repository (%s)", revision, seedRef.URL)
+	}
+	logger.Infof(" -> Checking out revision: %s", revision)
+	if _, err := runGitCommand(repoDirPth, "checkout", "--quiet", "--force", "--detach", revision); err != nil {
+		return resolvedSeedModel{}, err
+	}
+	if _, err := runGitCommand(repoDirPth, "clean", "--quiet", "-ffdx"); err != nil {
+		return resolvedSeedModel{}, err
+	}
+
+	seedDirPth := filepath.Join(repoDirPth, filepath.FromSlash(seedRef.SubDir))
+	isExist, err := pathutil.IsDirExists(seedDirPth)
+	if err != nil {
+		return resolvedSeedModel{}, err
+	}
+	if !isExist {
+		return resolvedSeedModel{}, fmt.Errorf("No directory found in seed repository (%s) at path: %s", seedRef.URL, seedRef.SubDir)
+	}
+
+	return resolvedSeedModel{
+		Ref:      seedRef,
+		Dir:      seedDirPth,
+		Revision: revision,
+	}, nil
+}
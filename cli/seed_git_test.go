@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func gitCommitForTest(t *testing.T, repoDirPth, message string) {
+	_, err := runGitCommand(repoDirPth, "add", "-A")
+	require.NoError(t, err)
+	_, err = runGitCommand(repoDirPth, "-c", "user.name=Garden Test", "-c", "user.email=garden@example.com",
+		"commit", "--quiet", "-m", message)
+	require.NoError(t, err)
+}
+
+// createTestSeedRepository ...
+//  creates a bare repository (returned) with a seed in the `seeds/apples` dir,
+//  and the working repository (in workDirPth) to make further changes
+func createTestSeedRepository(t *testing.T, tmpDir string) (string, string) {
+	workDirPth := filepath.Join(tmpDir, "work")
+	bareDirPth := filepath.Join(tmpDir, "seeds.git")
+	require.NoError(t, os.MkdirAll(filepath.Join(workDirPth, "seeds", "apples"), 0777))
+
+	_, err := runGitCommand(workDirPth, "init", "--quiet")
+	require.NoError(t, err)
+	_, err = runGitCommand(workDirPth, "checkout", "--quiet", "-b", "master")
+	require.NoError(t, err)
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(workDirPth, "seeds", "apples", "file.txt"), "v1\n"))
+	gitCommitForTest(t, workDirPth, "v1")
+	_, err = runGitCommand(workDirPth, "tag", "v1.0.0")
+	require.NoError(t, err)
+
+	_, err = runGitCommand("", "clone", "--quiet", "--bare", workDirPth, bareDirPth)
+	require.NoError(t, err)
+	_, err = runGitCommand(workDirPth, "remote", "add", "origin", bareDirPth)
+	require.NoError(t, err)
+
+	return bareDirPth, workDirPth
+}
+
+func Test_resolveSeed_git(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	bareDirPth, workDirPth := createTestSeedRepository(t, tmpDir)
+
+	t.Log("Default branch, with subdir - logged into the given logger")
+	plantLog := newPlantLog(true)
+	resolvedSeed, err := resolveSeed(gardenDirPth, "git+file://"+bareDirPth+"//seeds/apples", "", "", plantLog.Logger)
+	require.NoError(t, err)
+	require.Contains(t, plantLog.buffer.String(), "Cloning seed repository")
+	require.Contains(t, plantLog.buffer.String(), "Checking out revision")
+	require.Equal(t, filepath.Join(gitSeedCacheDirPath(gardenDirPth, "file://"+bareDirPth), "seeds", "apples"), resolvedSeed.Dir)
+	require.Equal(t, 40, len(resolvedSeed.Revision))
+	testFileContent(t, filepath.Join(resolvedSeed.Dir, "file.txt"), "v1\n")
+	v1Revision := resolvedSeed.Revision
+
+	t.Log("New commit on the branch - fetched into the existing cache")
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(workDirPth, "seeds", "apples", "file.txt"), "v2\n"))
+	gitCommitForTest(t, workDirPth, "v2")
+	_, err = runGitCommand(workDirPth, "push", "--quiet", "origin", "master")
+	require.NoError(t, err)
+
+	resolvedSeed, err = resolveSeed(gardenDirPth, "git+file://"+bareDirPth+"//seeds/apples@master", "", "", log.StandardLogger())
+	require.NoError(t, err)
+	require.NotEqual(t, v1Revision, resolvedSeed.Revision)
+	testFileContent(t, filepath.Join(resolvedSeed.Dir, "file.txt"), "v2\n")
+
+	t.Log("Tag")
+	resolvedSeed, err = resolveSeed(gardenDirPth, "git+file://"+bareDirPth+"//seeds/apples@v1.0.0", "", "", log.StandardLogger())
+	require.NoError(t, err)
+	require.Equal(t, v1Revision, resolvedSeed.Revision)
+	testFileContent(t, filepath.Join(resolvedSeed.Dir, "file.txt"), "v1\n")
+
+	t.Log("Commit hash")
+	resolvedSeed, err = resolveSeed(gardenDirPth, "git+file://"+bareDirPth+"//seeds/apples@"+v1Revision, "", "", log.StandardLogger())
+	require.NoError(t, err)
+	require.Equal(t, v1Revision, resolvedSeed.Revision)
+
+	t.Log("Unknown ref")
+	_, err = resolveSeed(gardenDirPth, "git+file://"+bareDirPth+"//seeds/apples@no-such-branch", "", "", log.StandardLogger())
+	require.Error(t, err)
+
+	t.Log("Unknown subdir")
+	_, err = resolveSeed(gardenDirPth, "git+file://"+bareDirPth+"//seeds/pears", "", "", log.StandardLogger())
+	require.Error(t, err)
+}
+
+func Test_growPlants_gitSeed(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	bareDirPth, _ := createTestSeedRepository(t, tmpDir)
+
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"git-1": config.PlantModel{
+				Path: plantDirPth,
+				Seed: "git+file://" + bareDirPth + "@v1.0.0",
+			},
+		},
+	}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"git-1"}, growOptionsModel{}))
+
+	testFileContent(t, filepath.Join(plantDirPth, "seeds", "apples", "file.txt"), "v1\n")
+	// the repository's .git dir should not get into the plant
+	isExist, err := pathutil.IsPathExists(filepath.Join(plantDirPth, ".git"))
+	require.NoError(t, err)
+	require.False(t, isExist)
+}
@@ -0,0 +1,641 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/codegangsta/cli"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// seedTestGrowRun : the host and timestamp are fixed for seed tests,
+//  so the rendered output doesn't depend on where and when the test runs
+var seedTestGrowRun = growRunModel{
+	Host: GardenTemplateHostModel{
+		Hostname: "garden-test-host",
+		User:     "garden",
+		OS:       "linux",
+		Arch:     "amd64",
+		HomeDir:  "/home/garden",
+	},
+	Timestamp: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// seedTestGardenDir : the template inventory's GardenDir for seed tests
+const seedTestGardenDir = "/garden"
+
+// hostDependentTemplateFunctions : template functions whose output depends on
+//  the environment garden runs in, seed tests can't make their output stable
+var hostDependentTemplateFunctions = map[string]bool{
+	"env": true,
+}
+
+// seedTestResultModel ...
+type seedTestResultModel struct {
+	Seed     string
+	TestCase string
+	// Warnings : the seed's templates which call host dependent template
+	//  functions (the same for every test case of the seed)
+	Warnings []string
+	// Diffs : the differences between the golden and the rendered output,
+	//  empty if the test passed
+	Diffs []string
+	// IsUpdated : whether the golden dir was rewritten (--update)
+	IsUpdated bool
+}
+
+// IsPassed ...
+func (result seedTestResultModel) IsPassed() bool {
+	return len(result.Diffs) == 0
+}
+
+// dirTreeEntryModel ...
+//  a file or symlink of a compared dir tree
+type dirTreeEntryModel struct {
+	IsSymlink    bool
+	Content      string
+	IsExecutable bool
+}
+
+// dirTreeDiffModel ...
+//  the difference of a single path of two compared dir trees
+type dirTreeDiffModel struct {
+	// Path : slash separated, relative to the compared dirs
+	Path string
+	// Diff : unified diff of the content, or the description
+	//  of the difference (symlink, executable flag)
+	Diff       string
+	Insertions int
+	Deletions  int
+}
+
+// collectDirTreeEntries ...
+//  the files and symlinks of the dir, by slash separated relative path;
+//  directories are not included (git doesn't store empty dirs either),
+//  and .git directories are skipped
+func collectDirTreeEntries(dirPth string) (map[string]dirTreeEntryModel, error) {
+	entries := map[string]dirTreeEntryModel{}
+	isExist, err := pathutil.IsDirExists(dirPth)
+	if err != nil || !isExist {
+		return entries, err
+	}
+
+	err = filepath.Walk(dirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() {
+			if fileInfo.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPth, err := filepath.Rel(dirPth, pth)
+		if err != nil {
+			return err
+		}
+
+		entry := dirTreeEntryModel{}
+		if fileInfo.Mode()&os.ModeSymlink != 0 {
+			entry.IsSymlink = true
+			entry.Content, err = os.Readlink(pth)
+		} else {
+			entry.IsExecutable = fileInfo.Mode()&0111 != 0
+			entry.Content, err = fileutil.ReadStringFromFile(pth)
+		}
+		if err != nil {
+			return err
+		}
+		entries[filepath.ToSlash(relPth)] = entry
+		return nil
+	})
+	return entries, err
+}
+
+// diffLines ...
+//  the lines of the content, with line endings (an empty content has no lines)
+func diffLines(content string) []string {
+	if content == "" {
+		return []string{}
+	}
+	return difflib.SplitLines(strings.TrimSuffix(content, "\n"))
+}
+
+func unifiedDiff(fromFile, fromContent, toFile, toContent string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(fromContent),
+		B:        diffLines(toContent),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+}
+
+// countChangedLines ...
+//  the number of inserted and deleted lines
+func countChangedLines(fromContent, toContent string) (int, int) {
+	insertions, deletions := 0, 0
+	matcher := difflib.NewMatcher(diffLines(fromContent), diffLines(toContent))
+	for _, anOpCode := range matcher.GetOpCodes() {
+		switch anOpCode.Tag {
+		case 'r':
+			deletions += anOpCode.I2 - anOpCode.I1
+			insertions += anOpCode.J2 - anOpCode.J1
+		case 'd':
+			deletions += anOpCode.I2 - anOpCode.I1
+		case 'i':
+			insertions += anOpCode.J2 - anOpCode.J1
+		}
+	}
+	return insertions, deletions
+}
+
+// diffDirTrees ...
+//  compares the files and symlinks of the two dirs, returns the differences
+//  sorted by path (unified diffs for content changes); the labels are
+//  used as the path prefixes in the diffs
+func diffDirTrees(fromDirPth, toDirPth, fromLabel, toLabel string) ([]dirTreeDiffModel, error) {
+	fromEntries, err := collectDirTreeEntries(fromDirPth)
+	if err != nil {
+		return []dirTreeDiffModel{}, fmt.Errorf("Failed to read %s dir (path:%s), error: %s", fromLabel, fromDirPth, err)
+	}
+	toEntries, err := collectDirTreeEntries(toDirPth)
+	if err != nil {
+		return []dirTreeDiffModel{}, fmt.Errorf("Failed to read %s dir (path:%s), error: %s", toLabel, toDirPth, err)
+	}
+
+	relPaths := []string{}
+	for aRelPth := range fromEntries {
+		relPaths = append(relPaths, aRelPth)
+	}
+	for aRelPth := range toEntries {
+		if _, isFound := fromEntries[aRelPth]; !isFound {
+			relPaths = append(relPaths, aRelPth)
+		}
+	}
+	sort.Strings(relPaths)
+
+	diffs := []dirTreeDiffModel{}
+	for _, aRelPth := range relPaths {
+		fromEntry, isInFrom := fromEntries[aRelPth]
+		toEntry, isInTo := toEntries[aRelPth]
+		fromFile := fromLabel + "/" + aRelPth
+		toFile := toLabel + "/" + aRelPth
+		if !isInFrom {
+			fromFile = "/dev/null"
+		}
+		if !isInTo {
+			toFile = "/dev/null"
+		}
+
+		if isInFrom && isInTo {
+			if fromEntry == toEntry {
+				continue
+			}
+			if fromEntry.IsSymlink || toEntry.IsSymlink {
+				diffs = append(diffs, dirTreeDiffModel{
+					Path: aRelPth,
+					Diff: fmt.Sprintf("%s: symlink differs (%s: %s, %s: %s)",
+						aRelPth, fromLabel, describeDirTreeEntry(fromEntry), toLabel, describeDirTreeEntry(toEntry)),
+				})
+				continue
+			}
+			if fromEntry.IsExecutable != toEntry.IsExecutable {
+				diffs = append(diffs, dirTreeDiffModel{
+					Path: aRelPth,
+					Diff: fmt.Sprintf("%s: executable flag differs (%s: %t, %s: %t)",
+						aRelPth, fromLabel, fromEntry.IsExecutable, toLabel, toEntry.IsExecutable),
+				})
+			}
+			if fromEntry.Content == toEntry.Content {
+				continue
+			}
+		}
+
+		diff, err := unifiedDiff(fromFile, fromEntry.Content, toFile, toEntry.Content)
+		if err != nil {
+			return []dirTreeDiffModel{}, err
+		}
+		if diff == "" {
+			// e.g. an empty file on one side only
+			diff = fmt.Sprintf("--- %s\n+++ %s\n", fromFile, toFile)
+		}
+		insertions, deletions := countChangedLines(fromEntry.Content, toEntry.Content)
+		diffs = append(diffs, dirTreeDiffModel{
+			Path:       aRelPth,
+			Diff:       diff,
+			Insertions: insertions,
+			Deletions:  deletions,
+		})
+	}
+	return diffs, nil
+}
+
+// compareDirTrees ...
+//  compares the files of the golden dir with the rendered output,
+//  returns the differences (unified diffs for content changes)
+func compareDirTrees(goldenDirPth, actualDirPth string) ([]string, error) {
+	dirTreeDiffs, err := diffDirTrees(goldenDirPth, actualDirPth, "golden", "actual")
+	if err != nil {
+		return []string{}, err
+	}
+	diffs := []string{}
+	for _, aDirTreeDiff := range dirTreeDiffs {
+		diffs = append(diffs, aDirTreeDiff.Diff)
+	}
+	return diffs, nil
+}
+
+func describeDirTreeEntry(entry dirTreeEntryModel) string {
+	if entry.IsSymlink {
+		return "-> " + entry.Content
+	}
+	return "regular file"
+}
+
+// renderSeedTestCase ...
+//  renders the seed with the test case's plant, into outDirPth
+func renderSeedTestCase(gardenDirAbsPth, seedName, seedDirPth, testCaseDirPth, outDirPth string) error {
+	testCase, err := config.LoadSeedTestCase(testCaseDirPth)
+	if err != nil {
+		return err
+	}
+	// seed tests are always strict
+	isStrict := true
+	gardenMap := config.GardenMapModel{
+		StrictVars: &isStrict,
+		Plants: map[string]config.PlantModel{
+			testCase.PlantID: config.PlantModel{
+				Path:  testCase.Path,
+				Seed:  seedName,
+				Vars:  testCase.Vars,
+				Zones: testCase.Zones,
+			},
+		},
+	}
+	templateInventory, err := createTemplateInventory(testCase.PlantID, gardenMap, seedTestGardenDir, seedTestGrowRun)
+	if err != nil {
+		return err
+	}
+
+	plantDirPth := filepath.Join(testCaseDirPth, config.SeedTestPlantDirName)
+	isPlantExist, err := pathutil.IsDirExists(plantDirPth)
+	if err != nil {
+		return err
+	}
+	if isPlantExist {
+		if _, err := copyDirContent(plantDirPth, outDirPth, false); err != nil {
+			return fmt.Errorf("Failed to copy the test case's plant dir, error: %s", err)
+		}
+	}
+
+	stagingDirPth, err := ioutil.TempDir("", "garden-seed-test-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(stagingDirPth); err != nil {
+			log.Warnf("Failed to remove temp dir (path:%s), error: %s", stagingDirPth, err)
+		}
+	}()
+	if _, err := renderSeed(gardenDirAbsPth, seedDirPth, stagingDirPth, plantDirPth, templateInventory, gardenMap, log.StandardLogger()); err != nil {
+		return err
+	}
+	_, err = copyDirContent(stagingDirPth, outDirPth, false)
+	return err
+}
+
+// collectTemplateFunctionCalls ...
+//  adds the names of the functions called in the template's parse tree to calledFuncs
+func collectTemplateFunctionCalls(node parse.Node, calledFuncs map[string]bool) {
+	switch typedNode := node.(type) {
+	case *parse.ListNode:
+		if typedNode == nil {
+			return
+		}
+		for _, aNode := range typedNode.Nodes {
+			collectTemplateFunctionCalls(aNode, calledFuncs)
+		}
+	case *parse.ActionNode:
+		collectTemplateFunctionCalls(typedNode.Pipe, calledFuncs)
+	case *parse.IfNode:
+		collectTemplateFunctionCallsInBranch(&typedNode.BranchNode, calledFuncs)
+	case *parse.RangeNode:
+		collectTemplateFunctionCallsInBranch(&typedNode.BranchNode, calledFuncs)
+	case *parse.WithNode:
+		collectTemplateFunctionCallsInBranch(&typedNode.BranchNode, calledFuncs)
+	case *parse.TemplateNode:
+		collectTemplateFunctionCalls(typedNode.Pipe, calledFuncs)
+	case *parse.PipeNode:
+		if typedNode == nil {
+			return
+		}
+		for _, aCmd := range typedNode.Cmds {
+			collectTemplateFunctionCalls(aCmd, calledFuncs)
+		}
+	case *parse.CommandNode:
+		for _, anArg := range typedNode.Args {
+			collectTemplateFunctionCalls(anArg, calledFuncs)
+		}
+	case *parse.ChainNode:
+		collectTemplateFunctionCalls(typedNode.Node, calledFuncs)
+	case *parse.IdentifierNode:
+		calledFuncs[typedNode.Ident] = true
+	}
+}
+
+func collectTemplateFunctionCallsInBranch(branch *parse.BranchNode, calledFuncs map[string]bool) {
+	collectTemplateFunctionCalls(branch.Pipe, calledFuncs)
+	collectTemplateFunctionCalls(branch.List, calledFuncs)
+	collectTemplateFunctionCalls(branch.ElseList, calledFuncs)
+}
+
+// hostDependentFunctionCallsInTemplate ...
+//  the sorted names of the host dependent template functions the template calls
+func hostDependentFunctionCallsInTemplate(name, content string, delimiters templateDelimitersModel) ([]string, error) {
+	if fileDelimiters, fileContent, isFound := extractDelimitersDirective(content); isFound {
+		delimiters = fileDelimiters
+		content = fileContent
+	}
+	tmpl, err := template.New(name).
+		Delims(delimiters.Left, delimiters.Right).
+		Funcs(templateEvaluationContextModel{}.templateFunctions()).
+		Parse(content)
+	if err != nil {
+		return []string{}, err
+	}
+	calledFuncs := map[string]bool{}
+	for _, aTmpl := range tmpl.Templates() {
+		if aTmpl.Tree != nil {
+			collectTemplateFunctionCalls(aTmpl.Tree.Root, calledFuncs)
+		}
+	}
+	funcNames := []string{}
+	for aFuncName := range calledFuncs {
+		if hostDependentTemplateFunctions[aFuncName] {
+			funcNames = append(funcNames, aFuncName)
+		}
+	}
+	sort.Strings(funcNames)
+	return funcNames, nil
+}
+
+// hostDependentFunctionCalls ...
+//  the seed's template files (seed relative path) and the partials
+//  (garden relative path) which call host dependent template functions,
+//  e.g. "README.md.template: env"
+func hostDependentFunctionCalls(gardenDirAbsPth, seedDirPth string) ([]string, error) {
+	seedConfig, err := config.LoadSeedConfig(seedDirPth)
+	if err != nil {
+		return []string{}, fmt.Errorf("Failed to load seed config, error: %s", err)
+	}
+	delimiters := templateDelimitersModel{}
+	if len(seedConfig.TemplateDelimiters) == 2 {
+		delimiters = templateDelimitersModel{Left: seedConfig.TemplateDelimiters[0], Right: seedConfig.TemplateDelimiters[1]}
+	}
+
+	calls := []string{}
+	addCalls := func(displayPth, name, content string, delimiters templateDelimitersModel) error {
+		funcNames, err := hostDependentFunctionCallsInTemplate(name, content, delimiters)
+		if err != nil {
+			return fmt.Errorf("Failed to parse template (path:%s), error: %s", name, err)
+		}
+		if len(funcNames) > 0 {
+			calls = append(calls, fmt.Sprintf("%s: %s", displayPth, strings.Join(funcNames, ", ")))
+		}
+		return nil
+	}
+
+	partials, err := loadTemplatePartials(
+		filepath.Join(gardenDirAbsPth, partialsDirName),
+		filepath.Join(seedDirPth, config.SeedMetaDirName, partialsDirName))
+	if err != nil {
+		return []string{}, fmt.Errorf("Failed to load template partials, error: %s", err)
+	}
+	for _, aPartial := range partials.Files {
+		displayPth, err := filepath.Rel(gardenDirAbsPth, aPartial.Path)
+		if err != nil {
+			displayPth = aPartial.Path
+		}
+		if err := addCalls(filepath.ToSlash(displayPth), aPartial.Path, aPartial.Content, aPartial.Delimiters); err != nil {
+			return []string{}, err
+		}
+	}
+
+	err = filepath.Walk(seedDirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() {
+			if pth != seedDirPth && (fileInfo.Name() == config.SeedMetaDirName || fileInfo.Name() == ".git") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(pth) != ".template" {
+			return nil
+		}
+		relPth, err := filepath.Rel(seedDirPth, pth)
+		if err != nil {
+			return err
+		}
+		content, err := fileutil.ReadStringFromFile(pth)
+		if err != nil {
+			return err
+		}
+		return addCalls(filepath.ToSlash(relPth), pth, content, delimiters)
+	})
+	if err != nil {
+		return []string{}, err
+	}
+	return calls, nil
+}
+
+// collectSeedTestCaseNames ...
+//  the names of the seed's test cases (the sub directories of the
+//  seed's tests dir), sorted
+func collectSeedTestCaseNames(seedDirPth string) ([]string, error) {
+	testsDirPth := config.SeedTestsDirPath(seedDirPth)
+	isExist, err := pathutil.IsDirExists(testsDirPth)
+	if err != nil || !isExist {
+		return []string{}, err
+	}
+
+	fileInfos, err := ioutil.ReadDir(testsDirPth)
+	if err != nil {
+		return []string{}, err
+	}
+	testCaseNames := []string{}
+	for _, aFileInfo := range fileInfos {
+		if aFileInfo.IsDir() {
+			testCaseNames = append(testCaseNames, aFileInfo.Name())
+		}
+	}
+	sort.Strings(testCaseNames)
+	return testCaseNames, nil
+}
+
+// collectTestedSeedNames ...
+//  the local seeds which have test cases, sorted
+func collectTestedSeedNames(gardenDirAbsPth string) ([]string, error) {
+	fileInfos, err := ioutil.ReadDir(filepath.Join(gardenDirAbsPth, "seeds"))
+	if err != nil {
+		return []string{}, err
+	}
+	seedNames := []string{}
+	for _, aFileInfo := range fileInfos {
+		if !aFileInfo.IsDir() {
+			continue
+		}
+		isExist, err := pathutil.IsDirExists(config.SeedTestsDirPath(filepath.Join(gardenDirAbsPth, "seeds", aFileInfo.Name())))
+		if err != nil {
+			return []string{}, err
+		}
+		if isExist {
+			seedNames = append(seedNames, aFileInfo.Name())
+		}
+	}
+	return seedNames, nil
+}
+
+// testSeeds ...
+//  renders every test case of the (local) seeds and compares the output
+//  with the test case's golden dir; if isUpdate is true the golden dirs
+//  are rewritten instead. If no seed is specified every seed with
+//  test cases is tested.
+func testSeeds(gardenDirAbsPth string, seedNames []string, isUpdate bool) ([]seedTestResultModel, error) {
+	if len(seedNames) == 0 {
+		testedSeedNames, err := collectTestedSeedNames(gardenDirAbsPth)
+		if err != nil {
+			return []seedTestResultModel{}, fmt.Errorf("Failed to collect seeds, error: %s", err)
+		}
+		seedNames = testedSeedNames
+	}
+
+	results := []seedTestResultModel{}
+	for _, aSeedName := range seedNames {
+		seedDirPth, err := checkSeedDir(gardenDirAbsPth, aSeedName)
+		if err != nil {
+			return []seedTestResultModel{}, err
+		}
+		testCaseNames, err := collectSeedTestCaseNames(seedDirPth)
+		if err != nil {
+			return []seedTestResultModel{}, fmt.Errorf("Failed to collect test cases of seed (%s), error: %s", aSeedName, err)
+		}
+		if len(testCaseNames) == 0 {
+			return []seedTestResultModel{}, fmt.Errorf("No test cases found for seed (%s) in: %s", aSeedName, config.SeedTestsDirPath(seedDirPth))
+		}
+		warnings, err := hostDependentFunctionCalls(gardenDirAbsPth, seedDirPth)
+		if err != nil {
+			return []seedTestResultModel{}, fmt.Errorf("Failed to check the templates of seed (%s), error: %s", aSeedName, err)
+		}
+
+		for _, aTestCaseName := range testCaseNames {
+			result, err := runSeedTestCase(gardenDirAbsPth, aSeedName, seedDirPth, aTestCaseName, isUpdate)
+			if err != nil {
+				return []seedTestResultModel{}, fmt.Errorf("Failed to run test case (%s) of seed (%s), error: %s", aTestCaseName, aSeedName, err)
+			}
+			result.Warnings = warnings
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+func runSeedTestCase(gardenDirAbsPth, seedName, seedDirPth, testCaseName string, isUpdate bool) (seedTestResultModel, error) {
+	result := seedTestResultModel{Seed: seedName, TestCase: testCaseName}
+	testCaseDirPth := filepath.Join(config.SeedTestsDirPath(seedDirPth), testCaseName)
+	goldenDirPth := filepath.Join(testCaseDirPth, config.SeedTestGoldenDirName)
+
+	outDirPth, err := ioutil.TempDir("", "garden-seed-test-out-")
+	if err != nil {
+		return seedTestResultModel{}, err
+	}
+	defer func() {
+		if err := os.RemoveAll(outDirPth); err != nil {
+			log.Warnf("Failed to remove temp dir (path:%s), error: %s", outDirPth, err)
+		}
+	}()
+
+	if err := renderSeedTestCase(gardenDirAbsPth, seedName, seedDirPth, testCaseDirPth, outDirPth); err != nil {
+		return seedTestResultModel{}, err
+	}
+
+	if isUpdate {
+		if err := os.RemoveAll(goldenDirPth); err != nil {
+			return seedTestResultModel{}, err
+		}
+		if err := os.MkdirAll(goldenDirPth, 0755); err != nil {
+			return seedTestResultModel{}, err
+		}
+		if _, err := copyDirContent(outDirPth, goldenDirPth, false); err != nil {
+			return seedTestResultModel{}, fmt.Errorf("Failed to update golden dir, error: %s", err)
+		}
+		result.IsUpdated = true
+		return result, nil
+	}
+
+	diffs, err := compareDirTrees(goldenDirPth, outDirPth)
+	if err != nil {
+		return seedTestResultModel{}, err
+	}
+	result.Diffs = diffs
+	return result, nil
+}
+
+func seedTest(c *cli.Context) {
+	log.Infoln("Seed test")
+
+	_, gardenDirAbsPth, err := config.LoadGardenMap("")
+	if err != nil {
+		log.Fatalf("Failed to load Garden Map: %s", err)
+	}
+
+	results, err := testSeeds(gardenDirAbsPth, c.Args(), c.Bool(UpdateKey))
+	if err != nil {
+		log.Fatalf("Failed to test seeds: %s", err)
+	}
+
+	failedCount := 0
+	isSeedWarned := map[string]bool{}
+	fmt.Println()
+	for _, aResult := range results {
+		if len(aResult.Warnings) > 0 && !isSeedWarned[aResult.Seed] {
+			isSeedWarned[aResult.Seed] = true
+			log.Warnf("%s %s: the output of these templates depends on the environment, it can differ on an other host:", colorstring.Yellow("[WARNING]"), aResult.Seed)
+			for _, aWarning := range aResult.Warnings {
+				log.Warnf(" - %s", aWarning)
+			}
+		}
+		testName := aResult.Seed + "/" + aResult.TestCase
+		if aResult.IsUpdated {
+			log.Infoln(colorstring.Yellow("[UPDATED]"), testName)
+			continue
+		}
+		if aResult.IsPassed() {
+			log.Infoln(colorstring.Green("[OK]"), testName)
+			continue
+		}
+		failedCount++
+		log.Infoln(colorstring.Red("[FAILED]"), testName)
+		for _, aDiff := range aResult.Diffs {
+			fmt.Println(aDiff)
+		}
+	}
+
+	if failedCount > 0 {
+		log.Fatalf("%d of %d seed test(s) failed - run `garden seed test --update` to update the goldens if the changes are expected", failedCount, len(results))
+	}
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestFile ...
+//  writes the file, creating its parent dirs
+func writeTestFile(t *testing.T, pth, content string, perm os.FileMode) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(pth), 0777))
+	require.NoError(t, fileutil.WriteStringToFileWithPermission(pth, content, perm))
+}
+
+func Test_compareDirTrees(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	goldenDirPth := filepath.Join(tmpDir, "golden")
+	actualDirPth := filepath.Join(tmpDir, "actual")
+
+	t.Log("No golden dir")
+	writeTestFile(t, filepath.Join(actualDirPth, "new.txt"), "new\n", 0644)
+	diffs, err := compareDirTrees(goldenDirPth, actualDirPth)
+	require.NoError(t, err)
+	require.Equal(t, []string{"--- /dev/null\n+++ actual/new.txt\n@@ -0,0 +1 @@\n+new\n"}, diffs)
+
+	t.Log("Changed, missing and executable files")
+	writeTestFile(t, filepath.Join(goldenDirPth, "new.txt"), "new\n", 0644)
+	writeTestFile(t, filepath.Join(goldenDirPth, "sub", "changed.txt"), "a\nb\n", 0644)
+	writeTestFile(t, filepath.Join(actualDirPth, "sub", "changed.txt"), "a\nc\n", 0644)
+	writeTestFile(t, filepath.Join(goldenDirPth, "missing.txt"), "missing\n", 0644)
+	writeTestFile(t, filepath.Join(actualDirPth, "run.sh"), "run\n", 0755)
+	writeTestFile(t, filepath.Join(goldenDirPth, "run.sh"), "run\n", 0644)
+	diffs, err = compareDirTrees(goldenDirPth, actualDirPth)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"--- golden/missing.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-missing\n",
+		"run.sh: executable flag differs (golden: false, actual: true)",
+		"--- golden/sub/changed.txt\n+++ actual/sub/changed.txt\n@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+	}, diffs)
+
+	t.Log("Symlinks")
+	require.NoError(t, os.Symlink("new.txt", filepath.Join(goldenDirPth, "link")))
+	require.NoError(t, os.Symlink("run.sh", filepath.Join(actualDirPth, "link")))
+	diffs, err = compareDirTrees(goldenDirPth, actualDirPth)
+	require.NoError(t, err)
+	require.Contains(t, diffs, "link: symlink differs (golden: -> new.txt, actual: -> run.sh)")
+}
+
+func Test_testSeeds(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	seedDirPth := filepath.Join(gardenDirPth, "seeds", "pears")
+	testsDirPth := filepath.Join(seedDirPth, ".seed", "tests")
+
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md.template"),
+		`Hello {{ var "Name" }} from {{ .PlantID }} at {{ .PlantPath }} on {{ .Host.Hostname }}`+"\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, ".gitignore.garden-append"), "build/\n", 0644)
+	require.NoError(t, os.MkdirAll(filepath.Join(gardenDirPth, "seeds", "untested"), 0777))
+
+	writeTestFile(t, filepath.Join(testsDirPth, "basic", "vars.yml"), "vars:\n  Name: Pear\n", 0644)
+	writeTestFile(t, filepath.Join(testsDirPth, "existing-plant", "vars.yml"),
+		"plant_id: pear-2\npath: /tmp/pear-2\nvars:\n  Name: Other\n", 0644)
+	writeTestFile(t, filepath.Join(testsDirPth, "existing-plant", "plant", ".gitignore"), "tmp/\n", 0644)
+
+	t.Log("No golden yet")
+	results, err := testSeeds(gardenDirPth, []string{}, false)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(results))
+	require.Equal(t, "pears", results[0].Seed)
+	require.Equal(t, "basic", results[0].TestCase)
+	require.False(t, results[0].IsPassed())
+
+	t.Log("Update the goldens")
+	results, err = testSeeds(gardenDirPth, []string{"pears"}, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(results))
+	require.True(t, results[0].IsUpdated)
+	testFileContent(t, filepath.Join(testsDirPth, "basic", "golden", "README.md"), "Hello Pear from basic at /plants/basic on garden-test-host\n")
+	testFileContent(t, filepath.Join(testsDirPth, "basic", "golden", ".gitignore"), "build/\n")
+	testFileContent(t, filepath.Join(testsDirPth, "existing-plant", "golden", "README.md"), "Hello Other from pear-2 at /tmp/pear-2 on garden-test-host\n")
+	testFileContent(t, filepath.Join(testsDirPth, "existing-plant", "golden", ".gitignore"), "tmp/\nbuild/\n")
+
+	t.Log("Passing")
+	results, err = testSeeds(gardenDirPth, []string{}, false)
+	require.NoError(t, err)
+	for _, aResult := range results {
+		require.True(t, aResult.IsPassed(), strings.Join(aResult.Diffs, "\n"))
+	}
+
+	t.Log("Seed changed")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md.template"), `Hi {{ var "Name" }}`+"\n", 0644)
+	results, err = testSeeds(gardenDirPth, []string{"pears"}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"--- golden/README.md\n+++ actual/README.md\n@@ -1 +1 @@\n-Hello Pear from basic at /plants/basic on garden-test-host\n+Hi Pear\n",
+	}, results[0].Diffs)
+
+	t.Log("Host dependent template functions - warning")
+	require.Equal(t, []string{}, results[0].Warnings)
+	writeTestFile(t, filepath.Join(seedDirPth, "sub", "home.txt.template"), `{{ if true }}{{ env "HOME" | upper }}{{ end }}`, 0644)
+	writeTestFile(t, filepath.Join(gardenDirPth, "partials", "shared.tmpl"), `{{ define "shared" }}{{ env "USER" }}{{ end }}`, 0644)
+	results, err = testSeeds(gardenDirPth, []string{"pears"}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"partials/shared.tmpl: env", "sub/home.txt.template: env"}, results[0].Warnings)
+
+	t.Log("Seed without test cases")
+	_, err = testSeeds(gardenDirPth, []string{"untested"}, false)
+	require.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/garden/config"
+)
+
+// applySeedLayout ...
+//  creates the directories and symlinks declared in the seed config,
+//  and applies the declared permissions, in the rendered seed dir
+func applySeedLayout(renderedDirPth string, seedConfig config.SeedConfigModel, evalContext templateEvaluationContextModel) error {
+	for _, aDirectory := range seedConfig.Directories {
+		evalContext.logger().Infof(" -> directory: %s", aDirectory)
+		if err := os.MkdirAll(filepath.Join(renderedDirPth, filepath.FromSlash(aDirectory)), 0755); err != nil {
+			return fmt.Errorf("Failed to create directory (%s), error: %s", aDirectory, err)
+		}
+	}
+
+	for _, aSymlink := range seedConfig.Symlinks {
+		target, err := evaluateTemplateString("symlink:"+aSymlink.Path, aSymlink.Target, evalContext)
+		if err != nil {
+			return fmt.Errorf("Failed to evaluate the target of symlink (%s), error: %s", aSymlink.Path, err)
+		}
+		evalContext.logger().Infof(" -> symlink: %s -> %s", aSymlink.Path, target)
+
+		linkPth := filepath.Join(renderedDirPth, filepath.FromSlash(aSymlink.Path))
+		if _, err := os.Lstat(linkPth); err == nil {
+			return fmt.Errorf("Can't create symlink (%s), the path is already defined in the seed", aSymlink.Path)
+		}
+		if err := os.MkdirAll(filepath.Dir(linkPth), 0755); err != nil {
+			return err
+		}
+		if err := os.Symlink(target, linkPth); err != nil {
+			return fmt.Errorf("Failed to create symlink (%s), error: %s", aSymlink.Path, err)
+		}
+	}
+
+	if len(seedConfig.Permissions) == 0 {
+		return nil
+	}
+	return filepath.Walk(renderedDirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if pth == renderedDirPth || fileInfo.Mode()&os.ModeSymlink != 0 {
+			// a symlink's permissions can't be changed
+			return nil
+		}
+		relPth, err := filepath.Rel(renderedDirPth, pth)
+		if err != nil {
+			return err
+		}
+		mode, isFound := seedConfig.PermissionForPath(filepath.ToSlash(relPth))
+		if !isFound {
+			return nil
+		}
+		evalContext.logger().Debugf(" -> permission: %s %#o", relPth, mode)
+		if err := os.Chmod(pth, mode); err != nil {
+			return fmt.Errorf("Failed to set permission of (%s), error: %s", relPth, err)
+		}
+		return nil
+	})
+}
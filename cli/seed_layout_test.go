@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testFilePermissions(t *testing.T, pth string, expectedPerm os.FileMode) {
+	fileInfo, err := os.Lstat(pth)
+	require.NoError(t, err)
+	require.Equal(t, expectedPerm, fileInfo.Mode().Perm(), pth)
+}
+
+func Test_applySeedLayout(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	writeTestFile(t, filepath.Join(tmpDir, "secrets.env"), "TOKEN=x\n", 0644)
+	writeTestFile(t, filepath.Join(tmpDir, "bin", "run.sh"), "run\n", 0644)
+	writeTestFile(t, filepath.Join(tmpDir, "README.md"), "readme\n", 0644)
+
+	evalContext := templateEvaluationContextModel{
+		Inventory: GardenTemplateInventoryModel{Vars: map[string]string{"ToolsDir": "/opt/tools"}},
+	}
+
+	t.Log("Directories, symlinks and permissions")
+	{
+		seedConfig := config.SeedConfigModel{
+			Permissions: []config.PermissionModel{
+				{Pattern: "*.env", Mode: "0600"},
+				{Pattern: "bin/*", Mode: "0755"},
+				{Pattern: "logs", Mode: "0700"},
+			},
+			Symlinks: []config.SymlinkModel{
+				{Path: "tools/lint", Target: `{{ var "ToolsDir" }}/lint`},
+			},
+			Directories: []string{"logs", "tmp/cache"},
+		}
+		require.NoError(t, applySeedLayout(tmpDir, seedConfig, evalContext))
+
+		testFilePermissions(t, filepath.Join(tmpDir, "secrets.env"), 0600)
+		testFilePermissions(t, filepath.Join(tmpDir, "bin", "run.sh"), 0755)
+		testFilePermissions(t, filepath.Join(tmpDir, "README.md"), 0644)
+		testFilePermissions(t, filepath.Join(tmpDir, "logs"), 0700)
+
+		isExist, err := pathutil.IsDirExists(filepath.Join(tmpDir, "tmp", "cache"))
+		require.NoError(t, err)
+		require.True(t, isExist)
+
+		linkTarget, err := os.Readlink(filepath.Join(tmpDir, "tools", "lint"))
+		require.NoError(t, err)
+		require.Equal(t, "/opt/tools/lint", linkTarget)
+	}
+
+	t.Log("Symlink path already defined by the seed")
+	{
+		seedConfig := config.SeedConfigModel{
+			Symlinks: []config.SymlinkModel{{Path: "README.md", Target: "other.md"}},
+		}
+		err := applySeedLayout(tmpDir, seedConfig, evalContext)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already defined in the seed")
+	}
+
+	t.Log("Symlink target with a missing var")
+	{
+		seedConfig := config.SeedConfigModel{
+			Symlinks: []config.SymlinkModel{{Path: "missing", Target: `{{ var "Missing" }}`}},
+		}
+		require.Error(t, applySeedLayout(tmpDir, seedConfig, evalContext))
+	}
+}
+
+func Test_replaceTemplateFilesInDir_symlink(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	writeTestFile(t, filepath.Join(tmpDir, "shared", "config.yml.template"), "app: {{ var \"AppName\" }}\n", 0644)
+	require.NoError(t, os.Symlink(filepath.Join("shared", "config.yml.template"), filepath.Join(tmpDir, "config.yml.template")))
+
+	evalContext := templateEvaluationContextModel{
+		Inventory: GardenTemplateInventoryModel{Vars: map[string]string{"AppName": "my-app"}},
+	}
+	require.NoError(t, replaceTemplateFilesInDir(tmpDir, evalContext))
+
+	t.Log("the template file is evaluated")
+	testFileContent(t, filepath.Join(tmpDir, "shared", "config.yml"), "app: my-app\n")
+
+	t.Log("the symlink is kept as it is")
+	linkTarget, err := os.Readlink(filepath.Join(tmpDir, "config.yml.template"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("shared", "config.yml.template"), linkTarget)
+	isExist, err := pathutil.IsPathExists(filepath.Join(tmpDir, "config.yml"))
+	require.NoError(t, err)
+	require.False(t, isExist)
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+)
+
+// resolvedSeedModel ...
+//  a seed, ready to be copied into a plant
+type resolvedSeedModel struct {
+	Ref config.SeedRefModel
+	// Dir : absolute path of the seed's directory
+	Dir string
+	// Revision : the resolved revision of a remote seed (e.g. git commit hash),
+	//  empty for local seeds
+	Revision string
+}
+
+// resolveSeed ...
+//  returns the directory of the seed, fetching it first if it's a remote seed.
+//  seedSHA256 is the expected checksum of archive seeds,
+//  lockedRevision is the git commit to use instead of the seed's ref (optional).
+//  The progress of fetching a remote seed is logged into logger.
+func resolveSeed(gardenDirAbsPth, seed, seedSHA256, lockedRevision string, logger *log.Logger) (resolvedSeedModel, error) {
+	seedRef, err := config.ParseSeedRef(seed)
+	if err != nil {
+		return resolvedSeedModel{}, err
+	}
+
+	switch seedRef.Source {
+	case config.SeedSourceLocal:
+		seedDirPth, err := checkSeedDir(gardenDirAbsPth, seedRef.Name)
+		if err != nil {
+			return resolvedSeedModel{}, err
+		}
+		return resolvedSeedModel{Ref: seedRef, Dir: seedDirPth}, nil
+	case config.SeedSourceGit:
+		return checkoutGitSeed(gardenDirAbsPth, seedRef, lockedRevision, logger)
+	case config.SeedSourceArchive:
+		return fetchArchiveSeed(gardenDirAbsPth, seedRef, seedSHA256, logger)
+	}
+	return resolvedSeedModel{}, fmt.Errorf("Unsupported seed source: %s", seedRef.Source)
+}
+
+// removeSeedOnlyFiles ...
+//  removes the files from a copied seed dir which should not get into the plant
+func removeSeedOnlyFiles(copiedSeedDirPth string) error {
+	for _, aName := range []string{config.SeedMetaDirName, ".git"} {
+		if err := os.RemoveAll(filepath.Join(copiedSeedDirPth, aName)); err != nil {
+			return fmt.Errorf("Failed to remove %s, error: %s", aName, err)
+		}
+	}
+	return nil
+}
+
+// seedContentHash ...
+//  a SHA256 hash of the seed directory's content: the relative paths,
+//  the file contents, symlink targets and whether a file is executable
+//  (other permission bits depend on the umask, those are not included).
+//  The .git dir is skipped.
+func seedContentHash(seedDirPth string) (string, error) {
+	hash := sha256.New()
+	err := filepath.Walk(seedDirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPth, err := filepath.Rel(seedDirPth, pth)
+		if err != nil {
+			return err
+		}
+		if relPth == "." {
+			return nil
+		}
+		relPth = filepath.ToSlash(relPth)
+
+		switch {
+		case fileInfo.IsDir():
+			if fileInfo.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			_, err = fmt.Fprintf(hash, "dir %s\n", relPth)
+		case fileInfo.Mode()&os.ModeSymlink != 0:
+			linkTarget, readErr := os.Readlink(pth)
+			if readErr != nil {
+				return readErr
+			}
+			_, err = fmt.Fprintf(hash, "symlink %s %s\n", relPth, linkTarget)
+		default:
+			_, err = fmt.Fprintf(hash, "file %s %t\n", relPth, fileInfo.Mode()&0111 != 0)
+			if err == nil {
+				err = copyFileInto(hash, pth)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("Failed to calculate content hash of seed (path:%s), error: %s", seedDirPth, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func copyFileInto(writer io.Writer, pth string) error {
+	file, err := os.Open(pth)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
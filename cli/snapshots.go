@@ -0,0 +1,399 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/codegangsta/cli"
+)
+
+// takePlantSnapshot ...
+//  saves the garden managed files of the plant (the files recorded in its
+//  state, which exist) and its merge bases into a new snapshot.
+//  The snapshot's dir is saved into the transaction first,
+//  so it's removed if the grow is rolled back.
+func takePlantSnapshot(gardenDirAbsPth, plantID, plantDirPth string, plantState config.PlantStateModel, takenAt time.Time, transaction *plantTransactionModel) (config.PlantSnapshotModel, error) {
+	snapshotID, err := config.NewPlantSnapshotID(gardenDirAbsPth, plantID, takenAt)
+	if err != nil {
+		return config.PlantSnapshotModel{}, err
+	}
+	snapshotDirPth := config.PlantSnapshotDirPath(gardenDirAbsPth, plantID, snapshotID)
+	if err := transaction.save(snapshotDirPth); err != nil {
+		return config.PlantSnapshotModel{}, err
+	}
+
+	snapshot := config.PlantSnapshotModel{
+		ID:         snapshotID,
+		PlantID:    plantID,
+		TakenAt:    takenAt,
+		PlantPath:  plantDirPth,
+		Files:      map[string]string{},
+		PlantState: plantState,
+	}
+	filesDirPth := filepath.Join(snapshotDirPth, config.SnapshotFilesDirName)
+	if err := os.MkdirAll(filesDirPth, 0755); err != nil {
+		return config.PlantSnapshotModel{}, err
+	}
+	for aPth := range plantState.Files {
+		plantFilePth := filepath.Join(plantDirPth, filepath.FromSlash(aPth))
+		fileInfo, err := os.Lstat(plantFilePth)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return config.PlantSnapshotModel{}, err
+		}
+		if !fileInfo.Mode().IsRegular() {
+			// garden only records regular files
+			continue
+		}
+		snapshotFilePth := filepath.Join(filesDirPth, filepath.FromSlash(aPth))
+		if err := os.MkdirAll(filepath.Dir(snapshotFilePth), 0755); err != nil {
+			return config.PlantSnapshotModel{}, err
+		}
+		if err := copyFileContent(plantFilePth, snapshotFilePth, fileInfo); err != nil {
+			return config.PlantSnapshotModel{}, fmt.Errorf("Failed to save file (%s), error: %s", aPth, err)
+		}
+		if snapshot.Files[aPth], err = fileSHA256(snapshotFilePth); err != nil {
+			return config.PlantSnapshotModel{}, err
+		}
+	}
+
+	mergeBasesDirPth := config.PlantMergeBasesDirPath(gardenDirAbsPth, plantID)
+	if isExist, err := pathutil.IsDirExists(mergeBasesDirPth); err != nil {
+		return config.PlantSnapshotModel{}, err
+	} else if isExist {
+		if _, err := copyDirContent(mergeBasesDirPth, filepath.Join(snapshotDirPth, config.SnapshotMergeBasesDirName), false); err != nil {
+			return config.PlantSnapshotModel{}, fmt.Errorf("Failed to save the merge bases, error: %s", err)
+		}
+	}
+
+	if err := config.SavePlantSnapshot(gardenDirAbsPth, snapshot); err != nil {
+		return config.PlantSnapshotModel{}, err
+	}
+	return snapshot, nil
+}
+
+// removeExpiredSnapshots ...
+//  removes the plant's snapshots which are not kept by the retention config
+func removeExpiredSnapshots(gardenDirAbsPth, plantID string, snapshotsConfig config.SnapshotsConfigModel, now time.Time, logger *log.Logger) error {
+	plantSnapshots, err := config.LoadPlantSnapshots(gardenDirAbsPth, plantID)
+	if err != nil {
+		return err
+	}
+	for _, aSnapshot := range config.ExpiredSnapshots(plantSnapshots, snapshotsConfig, now) {
+		logger.Infof("    removed expired snapshot: %s", aSnapshot.ID)
+		if err := os.RemoveAll(config.PlantSnapshotDirPath(gardenDirAbsPth, plantID, aSnapshot.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findSnapshot ...
+//  the snapshot with the ID, or the latest snapshot taken at or before
+//  the time (RFC3339 or the snapshot ID format), or the latest snapshot
+//  if at is empty. The snapshots are sorted from the oldest to the latest.
+func findSnapshot(snapshots []config.PlantSnapshotModel, at string) (config.PlantSnapshotModel, error) {
+	if len(snapshots) < 1 {
+		return config.PlantSnapshotModel{}, fmt.Errorf("No snapshots, take one with `garden grow --%s`", SnapshotKey)
+	}
+	if at == "" {
+		return snapshots[len(snapshots)-1], nil
+	}
+	for _, aSnapshot := range snapshots {
+		if aSnapshot.ID == at {
+			return aSnapshot, nil
+		}
+	}
+
+	atTime, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		if atTime, err = time.Parse(config.SnapshotIDTimeFormat, at); err != nil {
+			return config.PlantSnapshotModel{}, fmt.Errorf("Invalid snapshot ID or time (%s), use a snapshot ID (listed by `garden snapshots`) or an RFC3339 time", at)
+		}
+	}
+	for idx := len(snapshots) - 1; idx >= 0; idx-- {
+		if !snapshots[idx].TakenAt.After(atTime) {
+			return snapshots[idx], nil
+		}
+	}
+	return config.PlantSnapshotModel{}, fmt.Errorf("No snapshot taken at or before %s, the first one was taken at %s", at, snapshots[0].TakenAt.Format(time.RFC3339))
+}
+
+// restoreOptionsModel ...
+type restoreOptionsModel struct {
+	// At : the ID of the snapshot to restore, or a time: the latest snapshot
+	//  taken at or before it is restored (default: the latest snapshot)
+	At string
+	// Confirm : asks for confirmation before restoring,
+	//  nothing is asked if it's nil
+	Confirm confirmFunc
+}
+
+// restorePlant ...
+//  restores the files, the merge bases and the recorded state of the plant
+//  from one of its snapshots. The locally edited files which are overwritten
+//  are backed up first (like with the backup conflict policy), the files
+//  garden wrote into the plant since the snapshot are removed (unless edited
+//  locally). If the restore fails, the plant's changes are rolled back.
+func restorePlant(gardenDirAbsPth string, gardenMap config.GardenMapModel, plantID string, options restoreOptionsModel) error {
+	plantModel, isFound := gardenMap.Plants[plantID]
+	if !isFound {
+		return fmt.Errorf("Can't find Plant with ID: %s", plantID)
+	}
+	expandedPlantPath := plantModel.ExpandedPath(plantID)
+	plantDirPth, err := pathutil.AbsPath(expandedPlantPath)
+	if err != nil {
+		return fmt.Errorf("Failed to get Absolute path of plant (path:%s), error: %s", expandedPlantPath, err)
+	}
+
+	plantSnapshots, err := config.LoadPlantSnapshots(gardenDirAbsPth, plantID)
+	if err != nil {
+		return fmt.Errorf("Failed to load the snapshots of plant (%s), error: %s", plantID, err)
+	}
+	snapshot, err := findSnapshot(plantSnapshots, options.At)
+	if err != nil {
+		return fmt.Errorf("Failed to find the snapshot of plant (%s) to restore, error: %s", plantID, err)
+	}
+	snapshotDirPth := config.PlantSnapshotDirPath(gardenDirAbsPth, plantID, snapshot.ID)
+	snapshotFilesDirPth := filepath.Join(snapshotDirPth, config.SnapshotFilesDirName)
+	snapshotMergeBasesDirPth := filepath.Join(snapshotDirPth, config.SnapshotMergeBasesDirName)
+	mergeBasesDirPth := config.PlantMergeBasesDirPath(gardenDirAbsPth, plantID)
+
+	gardenState, err := config.LoadGardenState(gardenDirAbsPth)
+	if err != nil {
+		return fmt.Errorf("Failed to load garden state, error: %s", err)
+	}
+	plantState := gardenState.PlantState(plantID)
+	// the files garden wrote since the snapshot
+	newPths := []string{}
+	for aPth := range plantState.Files {
+		if _, isInSnapshot := snapshot.Files[aPth]; !isInSnapshot {
+			newPths = append(newPths, aPth)
+		}
+	}
+	newFiles, err := classifyRecordedFiles(plantDirPth, plantState, newPths)
+	if err != nil {
+		return fmt.Errorf("Failed to check the files of plant (%s), error: %s", plantID, err)
+	}
+	plannedCopy, err := copyDirContent(snapshotFilesDirPth, plantDirPth, true)
+	if err != nil {
+		return fmt.Errorf("Failed to compare the snapshot with the plant, error: %s", err)
+	}
+	restoredPths := []string{}
+	for _, aPath := range plannedCopy.Changed() {
+		if !aPath.IsDir {
+			restoredPths = append(restoredPths, aPath.Path)
+		}
+	}
+	// the overwritten files which garden didn't write this way
+	//  (edited locally, or not written by garden at all)
+	restoredFiles, err := classifyRecordedFiles(plantDirPth, plantState, restoredPths)
+	if err != nil {
+		return fmt.Errorf("Failed to check the files of plant (%s), error: %s", plantID, err)
+	}
+	backupPths := map[string]string{}
+	restoredAt := time.Now()
+	for _, aPth := range restoredFiles.Edited {
+		backupPths[aPth] = backupPathForConflict(aPth, restoredAt)
+	}
+
+	fmt.Println()
+	log.Infof("%s %s (%s)", colorstring.Yellow("==> restoring plant:"), colorstring.Green(plantID), plantDirPth)
+	log.Infof("    snapshot: %s (taken at %s)", snapshot.ID, snapshot.TakenAt.Local().Format(time.RFC3339))
+	for _, aPth := range restoredPths {
+		if backupPth, isEdited := backupPths[aPth]; isEdited {
+			log.Warnf("    %-12s %s (edited locally, backed up to %s)", "restore", aPth, backupPth)
+			continue
+		}
+		log.Infof("    %-12s %s", "restore", aPth)
+	}
+	for _, aPth := range newFiles.Unchanged {
+		log.Infof("    %-12s %s", "remove", aPth)
+	}
+	for _, aPth := range newFiles.Edited {
+		log.Warnf("    %-12s %s (edited locally)", "keep", aPth)
+	}
+	fmt.Println()
+
+	if len(restoredPths)+len(newFiles.Unchanged) > 0 && options.Confirm != nil {
+		question := fmt.Sprintf("Restore %d and remove %d file(s) of plant (%s)?", len(restoredPths), len(newFiles.Unchanged), plantID)
+		if len(backupPths) > 0 {
+			question = fmt.Sprintf("Restore %d (%d edited locally, backed up first) and remove %d file(s) of plant (%s)?", len(restoredPths), len(backupPths), len(newFiles.Unchanged), plantID)
+		}
+		isConfirmed, err := options.Confirm(question)
+		if err != nil {
+			return err
+		}
+		if !isConfirmed {
+			log.Warnln("Not confirmed, nothing was restored")
+			return nil
+		}
+	}
+
+	transaction, err := newPlantTransaction()
+	if err != nil {
+		return fmt.Errorf("Failed to create a temporary directory for the plant's backup: %s", err)
+	}
+	defer func() {
+		if err := transaction.cleanup(); err != nil {
+			log.Warnf("Failed to remove the plant's temporary backup dir, error: %s", err)
+		}
+	}()
+	logger := log.StandardLogger()
+	restoreErr := func() error {
+		for _, aPth := range restoredFiles.Edited {
+			if err := backupPlantFile(plantDirPth, aPth, backupPths[aPth], transaction); err != nil {
+				return err
+			}
+		}
+		if err := transaction.saveCopyTargets(snapshotFilesDirPth, plantDirPth); err != nil {
+			return fmt.Errorf("Failed to back up the plant's files, error: %s", err)
+		}
+		copyResult, err := copyDirContent(snapshotFilesDirPth, plantDirPth, false)
+		if err != nil {
+			return fmt.Errorf("Failed to restore the plant's files, error: %s", err)
+		}
+		logCopyResult(copyResult, plantDirPth, false, logger)
+
+		if err := removePlantFiles(plantDirPth, mergeBasesDirPth, newFiles.Unchanged, transaction, logger); err != nil {
+			return fmt.Errorf("Failed to remove the files garden wrote since the snapshot, error: %s", err)
+		}
+
+		// the merge bases of the files which are not in the snapshot
+		//  (e.g. the kept, locally edited files) are no longer needed
+		if err := removeMergeBasesNotIn(mergeBasesDirPth, snapshotMergeBasesDirPth, transaction); err != nil {
+			return fmt.Errorf("Failed to remove the merge bases which are not in the snapshot, error: %s", err)
+		}
+		if isExist, err := pathutil.IsDirExists(snapshotMergeBasesDirPth); err != nil {
+			return err
+		} else if isExist {
+			if err := transaction.saveCopyTargets(snapshotMergeBasesDirPth, mergeBasesDirPth); err != nil {
+				return fmt.Errorf("Failed to back up the merge bases, error: %s", err)
+			}
+			if _, err := copyDirContent(snapshotMergeBasesDirPth, mergeBasesDirPth, false); err != nil {
+				return fmt.Errorf("Failed to restore the merge bases, error: %s", err)
+			}
+		}
+		return nil
+	}()
+	if restoreErr != nil {
+		log.Warnln("--> Rolling back the plant's changes ...")
+		if err := transaction.rollback(); err != nil {
+			return fmt.Errorf("Failed to restore plant (%s), error: %s - and failed to roll back the plant's changes, error: %s", plantID, restoreErr, err)
+		}
+		return fmt.Errorf("Failed to restore plant (%s), error: %s", plantID, restoreErr)
+	}
+
+	// the files garden wrote since the snapshot, which were kept,
+	//  are no longer managed by garden
+	gardenState.Plants[plantID] = snapshot.PlantState
+	if err := config.SaveGardenState(gardenDirAbsPth, gardenState); err != nil {
+		return fmt.Errorf("Failed to save garden state, error: %s", err)
+	}
+	log.Infof("-> Plant restored from snapshot: %s", snapshot.ID)
+	return nil
+}
+
+// removeMergeBasesNotIn ...
+//  removes the merge bases which are not in the snapshot's merge bases dir,
+//  and the dirs which end up empty. The removed paths are saved into
+//  the transaction (if it's not nil) first.
+func removeMergeBasesNotIn(mergeBasesDirPth, snapshotMergeBasesDirPth string, transaction *plantTransactionModel) error {
+	if isExist, err := pathutil.IsDirExists(mergeBasesDirPth); err != nil || !isExist {
+		return err
+	}
+	stalePths := []string{}
+	err := filepath.Walk(mergeBasesDirPth, func(pth string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo.IsDir() {
+			return nil
+		}
+		relPth, err := filepath.Rel(mergeBasesDirPth, pth)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Lstat(filepath.Join(snapshotMergeBasesDirPth, relPth)); os.IsNotExist(err) {
+			stalePths = append(stalePths, pth)
+		} else if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, aPth := range stalePths {
+		if err := transaction.save(aPth); err != nil {
+			return err
+		}
+		if err := os.Remove(aPth); err != nil {
+			return err
+		}
+		if err := removeEmptyParentDirs(aPth, mergeBasesDirPth, transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func snapshots(c *cli.Context) {
+	log.Infoln("Snapshots")
+
+	gardenMap, gardenDirAbsPth, err := config.LoadGardenMap("")
+	if err != nil {
+		log.Fatalf("Failed to load Garden Map: %s", err)
+	}
+
+	plantIDs := gardenMap.FilteredPlantsIDs(WorkWithPlantID, WorkWithZone)
+	sort.Strings(plantIDs)
+	for _, aPlantID := range plantIDs {
+		plantSnapshots, err := config.LoadPlantSnapshots(gardenDirAbsPth, aPlantID)
+		if err != nil {
+			log.Fatalf("Failed to load the snapshots of plant (%s): %s", aPlantID, err)
+		}
+		fmt.Println()
+		log.Infof("%s %s", colorstring.Yellow("==> plant:"), colorstring.Green(aPlantID))
+		if len(plantSnapshots) < 1 {
+			log.Infoln("    no snapshots")
+			continue
+		}
+		for _, aSnapshot := range plantSnapshots {
+			log.Infof("    %s  taken at %s  %d file(s)", aSnapshot.ID, aSnapshot.TakenAt.Local().Format(time.RFC3339), len(aSnapshot.Files))
+		}
+	}
+}
+
+func restore(c *cli.Context) {
+	log.Infoln("Restore")
+
+	gardenMap, gardenDirAbsPth, err := config.LoadGardenMap("")
+	if err != nil {
+		log.Fatalf("Failed to load Garden Map: %s", err)
+	}
+
+	plantID := c.String(PlantKey)
+	if plantID == "" {
+		plantID = WorkWithPlantID
+	}
+	if plantID == "" {
+		log.Fatalf("No plant specified, specify the plant to restore with --%s", PlantKey)
+	}
+	options := restoreOptionsModel{At: c.String(AtKey)}
+	if !c.Bool(YesKey) {
+		options.Confirm = askForConfirmation
+	}
+	if err := restorePlant(gardenDirAbsPth, gardenMap, plantID, options); err != nil {
+		log.Fatalf("Failed to restore plant: %s", err)
+	}
+}
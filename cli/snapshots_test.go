@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_findSnapshot(t *testing.T) {
+	takenAt := time.Date(2016, 3, 4, 10, 0, 0, 0, time.UTC)
+	snapshots := []config.PlantSnapshotModel{
+		config.PlantSnapshotModel{ID: "20160304T100000Z", TakenAt: takenAt},
+		config.PlantSnapshotModel{ID: "20160304T110000Z", TakenAt: takenAt.Add(time.Hour)},
+		config.PlantSnapshotModel{ID: "20160304T120000Z", TakenAt: takenAt.Add(2 * time.Hour)},
+	}
+
+	for at, expectedID := range map[string]string{
+		"":                          "20160304T120000Z",
+		"20160304T110000Z":          "20160304T110000Z",
+		"20160304T113000Z":          "20160304T110000Z",
+		"2016-03-04T12:30:00+01:00": "20160304T110000Z",
+		"2016-03-05T00:00:00Z":      "20160304T120000Z",
+	} {
+		snapshot, err := findSnapshot(snapshots, at)
+		require.NoError(t, err, at)
+		require.Equal(t, expectedID, snapshot.ID, at)
+	}
+
+	t.Log("Not found")
+	_, err := findSnapshot(snapshots, "2016-03-04T09:00:00Z")
+	require.Error(t, err)
+	_, err = findSnapshot(snapshots, "yesterday")
+	require.Error(t, err)
+	_, err = findSnapshot([]config.PlantSnapshotModel{}, "")
+	require.Error(t, err)
+}
+
+func Test_restorePlant(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	seedDirPth := filepath.Join(gardenDirPth, "seeds", "app")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "readme v1\n", 0644)
+
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	gardenMap := config.GardenMapModel{
+		Snapshots: &config.SnapshotsConfigModel{Keep: 2},
+		Plants: map[string]config.PlantModel{
+			"app-1": config.PlantModel{Path: plantDirPth, Seed: "app"},
+		},
+	}
+
+	t.Log("No snapshot of a plant which was not grown before")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsSnapshot: true}))
+	snapshots, err := config.LoadPlantSnapshots(gardenDirPth, "app-1")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(snapshots))
+	grownState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+
+	t.Log("Snapshot before the grow")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "readme v2\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "src", "main.go"), "package main\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "src", "edited.go"), "package main\n", 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsSnapshot: true}))
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "readme v2\n")
+	snapshots, err = config.LoadPlantSnapshots(gardenDirPth, "app-1")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(snapshots))
+	require.Equal(t, []string{"README.md"}, func() []string {
+		pths := []string{}
+		for aPth := range snapshots[0].Files {
+			pths = append(pths, aPth)
+		}
+		return pths
+	}())
+	testFileContent(t, filepath.Join(config.PlantSnapshotDirPath(gardenDirPth, "app-1", snapshots[0].ID), config.SnapshotFilesDirName, "README.md"), "readme v1\n")
+
+	t.Log("Not confirmed - nothing is restored, the locally edited files are flagged")
+	writeTestFile(t, filepath.Join(plantDirPth, "src", "edited.go"), "package edited\n", 0644)
+	writeTestFile(t, filepath.Join(plantDirPth, "README.md"), "readme v2, edited\n", 0644)
+	askedQuestion := ""
+	declineConfirm := func(question string) (bool, error) {
+		askedQuestion = question
+		return false, nil
+	}
+	require.NoError(t, restorePlant(gardenDirPth, gardenMap, "app-1", restoreOptionsModel{Confirm: declineConfirm}))
+	require.Equal(t, "Restore 1 (1 edited locally, backed up first) and remove 1 file(s) of plant (app-1)?", askedQuestion)
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "readme v2, edited\n")
+
+	t.Log("Restore - the snapshot's files, merge bases and state are restored, the new files are removed unless edited")
+	mergeBasesDirPth := config.PlantMergeBasesDirPath(gardenDirPth, "app-1")
+	testPathExists(t, filepath.Join(mergeBasesDirPth, "src", "edited.go"), true)
+	require.NoError(t, restorePlant(gardenDirPth, gardenMap, "app-1", restoreOptionsModel{}))
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "readme v1\n")
+	testPathExists(t, filepath.Join(plantDirPth, "src", "main.go"), false)
+	testFileContent(t, filepath.Join(plantDirPth, "src", "edited.go"), "package edited\n")
+	testFileContent(t, filepath.Join(mergeBasesDirPth, "README.md"), "readme v1\n")
+	testPathExists(t, filepath.Join(mergeBasesDirPth, "src"), false)
+
+	t.Log("The overwritten, locally edited file is backed up")
+	backupPths, err := filepath.Glob(filepath.Join(plantDirPth, "README.md.*.garden-backup"))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(backupPths))
+	testFileContent(t, backupPths[0], "readme v2, edited\n")
+	restoredState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+	require.Equal(t, grownState, restoredState)
+
+	t.Log("Retention - the latest 2 snapshots are kept")
+	for idx := 0; idx < 3; idx++ {
+		require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsSnapshot: true, IsForce: true, ConflictPolicy: config.ConflictPolicySkip}))
+	}
+	snapshots, err = config.LoadPlantSnapshots(gardenDirPth, "app-1")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(snapshots))
+
+	t.Log("Unknown snapshot")
+	require.Error(t, restorePlant(gardenDirPth, gardenMap, "app-1", restoreOptionsModel{At: "2000-01-01T00:00:00Z"}))
+}
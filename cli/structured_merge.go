@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// mergeMapSlices ...
+//  deep merges the overlay into the base:
+//  maps are merged recursively, every other value (scalars and lists)
+//  of the overlay replaces the base's value.
+//  The order of the base's keys is kept, new keys are appended.
+func mergeMapSlices(base, overlay yaml.MapSlice) yaml.MapSlice {
+	merged := append(yaml.MapSlice{}, base...)
+	for _, anOverlayItem := range overlay {
+		isFound := false
+		for idx, aMergedItem := range merged {
+			if aMergedItem.Key != anOverlayItem.Key {
+				continue
+			}
+			isFound = true
+			baseMap, isBaseMap := aMergedItem.Value.(yaml.MapSlice)
+			overlayMap, isOverlayMap := anOverlayItem.Value.(yaml.MapSlice)
+			if isBaseMap && isOverlayMap {
+				merged[idx].Value = mergeMapSlices(baseMap, overlayMap)
+			} else {
+				merged[idx].Value = anOverlayItem.Value
+			}
+			break
+		}
+		if !isFound {
+			merged = append(merged, anOverlayItem)
+		}
+	}
+	return merged
+}
+
+func parseStructuredContent(content string, isJSON bool) (yaml.MapSlice, error) {
+	parsed := yaml.MapSlice{}
+	if strings.TrimSpace(content) == "" {
+		return parsed, nil
+	}
+	if isJSON {
+		return parseOrderedJSON(content)
+	}
+	if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+		return yaml.MapSlice{}, err
+	}
+	return parsed, nil
+}
+
+// parseOrderedJSON ...
+//  parses a JSON object into a MapSlice, keeping the order of the keys;
+//  numbers are kept as they are written (json.Number)
+func parseOrderedJSON(content string) (yaml.MapSlice, error) {
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+	value, err := decodeOrderedJSONValue(decoder)
+	if err != nil {
+		return yaml.MapSlice{}, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return yaml.MapSlice{}, errors.New("Invalid JSON, unexpected content after the top level object")
+	}
+	object, isObject := value.(yaml.MapSlice)
+	if !isObject {
+		return yaml.MapSlice{}, errors.New("Invalid JSON, the top level value is not an object")
+	}
+	return object, nil
+}
+
+func decodeOrderedJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := yaml.MapSlice{}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, yaml.MapItem{Key: keyToken, Value: value})
+		}
+		// the closing }
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return object, nil
+	case json.Delim('['):
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := decodeOrderedJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		// the closing ]
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	return token, nil
+}
+
+// writeOrderedJSON ...
+//  writes the value as indented JSON, keeping the order of the keys of MapSlices
+func writeOrderedJSON(buf *bytes.Buffer, value interface{}, indent string) error {
+	const indentUnit = "  "
+
+	switch typedValue := value.(type) {
+	case yaml.MapSlice:
+		if len(typedValue) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for idx, anItem := range typedValue {
+			keyBytes, err := json.Marshal(fmt.Sprint(anItem.Key))
+			if err != nil {
+				return err
+			}
+			buf.WriteString(indent + indentUnit)
+			buf.Write(keyBytes)
+			buf.WriteString(": ")
+			if err := writeOrderedJSON(buf, anItem.Value, indent+indentUnit); err != nil {
+				return err
+			}
+			if idx < len(typedValue)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		if len(typedValue) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for idx, anItem := range typedValue {
+			buf.WriteString(indent + indentUnit)
+			if err := writeOrderedJSON(buf, anItem, indent+indentUnit); err != nil {
+				return err
+			}
+			if idx < len(typedValue)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+	default:
+		valueBytes, err := json.Marshal(typedValue)
+		if err != nil {
+			return err
+		}
+		buf.Write(valueBytes)
+	}
+	return nil
+}
+
+// mergeStructuredContent ...
+//  deep merges the overlay JSON or YAML content into the base content.
+//  The format is determined by the file path's extension. JSON is
+//  parsed with encoding/json, numbers are written back as they were.
+func mergeStructuredContent(filePth, baseContent, overlayContent string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(filePth))
+	if ext != ".json" && ext != ".yml" && ext != ".yaml" {
+		return "", fmt.Errorf("Unsupported file type for merge: %s (supported: .json, .yml, .yaml)", filePth)
+	}
+
+	isJSON := ext == ".json"
+	base, err := parseStructuredContent(baseContent, isJSON)
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse the existing file's content, error: %s", err)
+	}
+	overlay, err := parseStructuredContent(overlayContent, isJSON)
+	if err != nil {
+		return "", fmt.Errorf("Failed to parse the content to merge, error: %s", err)
+	}
+	merged := mergeMapSlices(base, overlay)
+
+	if isJSON {
+		var buf bytes.Buffer
+		if err := writeOrderedJSON(&buf, merged, ""); err != nil {
+			return "", fmt.Errorf("Failed to serialize merged JSON, error: %s", err)
+		}
+		buf.WriteString("\n")
+		return buf.String(), nil
+	}
+
+	mergedBytes, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", fmt.Errorf("Failed to serialize merged YAML, error: %s", err)
+	}
+	return string(mergedBytes), nil
+}
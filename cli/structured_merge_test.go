@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mergeStructuredContent(t *testing.T) {
+	t.Log("JSON - deep merge, keeps the order of the existing keys")
+	merged, err := mergeStructuredContent("config.json", `{
+  "name": "my-app",
+  "settings": {"b": 1, "a": true},
+  "list": [1, 2]
+}`, `{"settings": {"c": "new", "a": false}, "list": ["x"], "added": null}`)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "name": "my-app",
+  "settings": {
+    "b": 1,
+    "a": false,
+    "c": "new"
+  },
+  "list": [
+    "x"
+  ],
+  "added": null
+}
+`, merged)
+
+	t.Log("JSON - numbers are kept as they are written")
+	merged, err = mergeStructuredContent("package.json", `{"version": 1.0, "size": 1e3, "name": "a"}`, `{"name": "b", "ratio": 0.50}`)
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "version": 1.0,
+  "size": 1e3,
+  "name": "b",
+  "ratio": 0.50
+}
+`, merged)
+
+	t.Log("YAML")
+	merged, err = mergeStructuredContent("config.yml", `name: my-app
+settings:
+  b: 1
+`, `settings:
+  c: new
+`)
+	require.NoError(t, err)
+	require.Equal(t, `name: my-app
+settings:
+  b: 1
+  c: new
+`, merged)
+
+	t.Log("Merging into an empty / non existing file")
+	merged, err = mergeStructuredContent("config.yaml", "", "a: 1\n")
+	require.NoError(t, err)
+	require.Equal(t, "a: 1\n", merged)
+
+	t.Log("Unsupported file type")
+	_, err = mergeStructuredContent("config.toml", "", "")
+	require.EqualError(t, err, "Unsupported file type for merge: config.toml (supported: .json, .yml, .yaml)")
+
+	t.Log("Not a map")
+	_, err = mergeStructuredContent("config.json", "[1, 2]", "{}")
+	require.Error(t, err)
+	_, err = mergeStructuredContent("config.json", "{} {}", "{}")
+	require.Error(t, err)
+}
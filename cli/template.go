@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+)
+
+// delimitersDirectiveRegexp : the per-file delimiters directive,
+//  which can be specified in the first line of a template or partial file,
+//  the line has to be a comment which includes only the directive,
+//  e.g.: `# garden:delims [[ ]]` or `{{/* garden:delims [[ ]] */}}`.
+//  Groups: comment opener, left and right delimiter, comment closer
+var delimitersDirectiveRegexp = regexp.MustCompile(`^\s*(#|//|--|;|<!--|/\*|\{\{-?\s*/\*)\s*garden:delims\s+(\S+)\s+(\S+)(?:\s+(-->|\*/\s*-?\}\}|\*/))?\s*$`)
+
+// templateDelimitersModel ...
+//  empty delimiters mean the default {{ and }}
+type templateDelimitersModel struct {
+	Left  string
+	Right string
+}
+
+// templatePartialFileModel ...
+type templatePartialFileModel struct {
+	Path       string
+	Content    string
+	Delimiters templateDelimitersModel
+}
+
+// templatePartialsModel ...
+//  holds the partial files (files with {{ define }} blocks) which
+//  should be available for every template of a plant
+type templatePartialsModel struct {
+	Files []templatePartialFileModel
+}
+
+// templateEvaluationContextModel ...
+//  everything required to evaluate the templates of a plant
+type templateEvaluationContextModel struct {
+	Inventory GardenTemplateInventoryModel
+	GardenMap config.GardenMapModel
+	Partials  templatePartialsModel
+	// Delimiters : the default delimiters of the templates,
+	//  can be overwritten by a template file's delimiters directive
+	Delimiters templateDelimitersModel
+	// IsStrict : missing map keys (e.g. {{ .Vars.Missing }}) are errors
+	IsStrict bool
+	// Logger : the log of the plant's render (the standard logger if nil)
+	Logger *log.Logger
+}
+
+// logger ...
+func (evalContext templateEvaluationContextModel) logger() *log.Logger {
+	if evalContext.Logger == nil {
+		return log.StandardLogger()
+	}
+	return evalContext.Logger
+}
+
+// templateFunctions ...
+//  every template function which is available for the templates
+func (evalContext templateEvaluationContextModel) templateFunctions() template.FuncMap {
+	funcs := createAvailableTemplateFunctions(evalContext.Inventory)
+	for name, fn := range gardenMapTemplateFunctions(evalContext.GardenMap) {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// extractDelimitersDirective ...
+//  if the first line of the content is a delimiters directive
+//  it returns the delimiters and the content without the directive line.
+//  A block comment (e.g. {{/* */}}) has to be closed in the same line,
+//  with a whitespace between the right delimiter and the comment's closer.
+func extractDelimitersDirective(content string) (templateDelimitersModel, string, bool) {
+	firstLine := content
+	rest := ""
+	if idx := strings.Index(content, "\n"); idx >= 0 {
+		firstLine = content[:idx]
+		rest = content[idx+1:]
+	}
+
+	matches := delimitersDirectiveRegexp.FindStringSubmatch(firstLine)
+	if len(matches) != 5 {
+		return templateDelimitersModel{}, content, false
+	}
+	opener, closer := matches[1], matches[4]
+	isBlockComment := opener == "<!--" || strings.HasSuffix(opener, "/*")
+	if isBlockComment != (closer != "") {
+		return templateDelimitersModel{}, content, false
+	}
+	return templateDelimitersModel{Left: matches[2], Right: matches[3]}, rest, true
+}
+
+func collectPartialFilePathsInDir(dirPth string) ([]string, error) {
+	isExist, err := pathutil.IsDirExists(dirPth)
+	if err != nil {
+		return []string{}, err
+	}
+	if !isExist {
+		return []string{}, nil
+	}
+
+	pths, err := filepath.Glob(filepath.Join(dirPth, "*"+partialFileExt))
+	if err != nil {
+		return []string{}, err
+	}
+	sort.Strings(pths)
+	return pths, nil
+}
+
+// loadTemplatePartials ...
+//  loads every partial file (*.tmpl) from the given directories,
+//  in the order the directories are provided.
+// Non existing directories are skipped.
+// Returns an error if the same template name is defined in more than one file.
+func loadTemplatePartials(partialsDirPaths ...string) (templatePartialsModel, error) {
+	partials := templatePartialsModel{}
+	definedIn := map[string]string{}
+
+	for _, aDirPth := range partialsDirPaths {
+		partialFilePaths, err := collectPartialFilePathsInDir(aDirPth)
+		if err != nil {
+			return templatePartialsModel{}, fmt.Errorf("Failed to scan partials directory (path:%s), error: %s", aDirPth, err)
+		}
+
+		for _, aPartialPth := range partialFilePaths {
+			log.Debugf("-> Loading template partial: %s", aPartialPth)
+			content, err := fileutil.ReadStringFromFile(aPartialPth)
+			if err != nil {
+				return templatePartialsModel{}, fmt.Errorf("Failed to read partial file (path:%s), error: %s", aPartialPth, err)
+			}
+			delimiters, content, _ := extractDelimitersDirective(content)
+
+			tmpl, err := template.New(aPartialPth).
+				Delims(delimiters.Left, delimiters.Right).
+				Funcs(templateEvaluationContextModel{}.templateFunctions()).
+				Parse(content)
+			if err != nil {
+				return templatePartialsModel{}, fmt.Errorf("Failed to parse partial file (path:%s), error: %s", aPartialPth, err)
+			}
+			for _, aDefinedTmpl := range tmpl.Templates() {
+				name := aDefinedTmpl.Name()
+				if name == aPartialPth {
+					// the file's root template, not a {{ define }} block
+					continue
+				}
+				if otherPth, isFound := definedIn[name]; isFound {
+					return templatePartialsModel{}, fmt.Errorf("Template partial %q is defined in multiple files: %s and %s", name, otherPth, aPartialPth)
+				}
+				definedIn[name] = aPartialPth
+			}
+
+			partials.Files = append(partials.Files, templatePartialFileModel{
+				Path:       aPartialPth,
+				Content:    content,
+				Delimiters: delimiters,
+			})
+		}
+	}
+
+	return partials, nil
+}
+
+// evaluateTemplateString ...
+//  evaluates the template content, with the provided partials
+//  made available for {{ template "name" . }} calls
+func evaluateTemplateString(templateName, templateContent string, evalContext templateEvaluationContextModel) (string, error) {
+	delimiters := evalContext.Delimiters
+	if fileDelimiters, content, isFound := extractDelimitersDirective(templateContent); isFound {
+		delimiters = fileDelimiters
+		templateContent = content
+	}
+
+	tmpl := template.New(templateName).Funcs(evalContext.templateFunctions())
+	if evalContext.IsStrict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+	for _, aPartial := range evalContext.Partials.Files {
+		if _, err := tmpl.New(aPartial.Path).
+			Delims(aPartial.Delimiters.Left, aPartial.Delimiters.Right).
+			Parse(aPartial.Content); err != nil {
+			return "", fmt.Errorf("Failed to parse partial file (path:%s), error: %s", aPartial.Path, err)
+		}
+	}
+	if _, err := tmpl.Delims(delimiters.Left, delimiters.Right).Parse(templateContent); err != nil {
+		return "", err
+	}
+
+	var resBuffer bytes.Buffer
+	if err := tmpl.Execute(&resBuffer, evalContext.Inventory); err != nil {
+		return "", err
+	}
+	return resBuffer.String(), nil
+}
@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"gopkg.in/yaml.v2"
+)
+
+// standardTemplateFunctions ...
+//  the general purpose template functions, which don't depend on
+//  the plant's inventory.
+//  For the list of functions and examples see the README.
+func standardTemplateFunctions() template.FuncMap {
+	return template.FuncMap{
+		// string case conversion
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      toTitleCase,
+		"camelCase":  toCamelCase,
+		"pascalCase": toPascalCase,
+		"snakeCase":  toSnakeCase,
+		"kebabCase":  toKebabCase,
+		// string manipulation
+		"trim": strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string {
+			return strings.TrimPrefix(s, prefix)
+		},
+		"trimSuffix": func(suffix, s string) string {
+			return strings.TrimSuffix(s, suffix)
+		},
+		"replace": func(old, new, s string) string {
+			return strings.Replace(s, old, new, -1)
+		},
+		"split": func(sep, s string) []string {
+			return strings.Split(s, sep)
+		},
+		"join": func(sep string, elems []string) string {
+			return strings.Join(elems, sep)
+		},
+		"indent":  indentLines,
+		"nindent": func(spaces int, s string) string {
+			return "\n" + indentLines(spaces, s)
+		},
+		// value checks
+		"default": func(defaultValue, val string) string {
+			if val == "" {
+				return defaultValue
+			}
+			return val
+		},
+		"required": func(errorMessage, val string) (string, error) {
+			if val == "" {
+				return "", errors.New(errorMessage)
+			}
+			return val, nil
+		},
+		// environment
+		"env": os.Getenv,
+		// paths
+		"pathJoin": path.Join,
+		"pathBase": path.Base,
+		"pathDir":  path.Dir,
+		// serialization
+		"toJSON": func(v interface{}) (string, error) {
+			bytes, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return string(bytes), nil
+		},
+		"toYAML": func(v interface{}) (string, error) {
+			bytes, err := yaml.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(string(bytes), "\n"), nil
+		},
+		// regex
+		"regexMatch": regexp.MatchString,
+		"regexReplaceAll": func(pattern, repl, s string) (string, error) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return "", err
+			}
+			return re.ReplaceAllString(s, repl), nil
+		},
+		// encoding & hashing
+		"b64enc": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"b64dec": func(s string) (string, error) {
+			bytes, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return "", err
+			}
+			return string(bytes), nil
+		},
+		"sha256sum": func(s string) string {
+			sum := sha256.Sum256([]byte(s))
+			return hex.EncodeToString(sum[:])
+		},
+		"uuid": nameBasedUUID,
+	}
+}
+
+// GardenTemplatePlantModel ...
+//  a plant of the garden, as returned by the plant lookup template functions
+type GardenTemplatePlantModel struct {
+	ID   string
+	Path string
+	Vars map[string]string
+}
+
+func createTemplatePlantModel(gardenMap config.GardenMapModel, plantID string) (GardenTemplatePlantModel, error) {
+	plantModel, isFound := gardenMap.Plants[plantID]
+	if !isFound {
+		return GardenTemplatePlantModel{}, fmt.Errorf("No plant found with ID: %s", plantID)
+	}
+
+	expandedPlantPath := plantModel.ExpandedPath(plantID)
+	absPlantPath, err := pathutil.AbsPath(expandedPlantPath)
+	if err != nil {
+		return GardenTemplatePlantModel{}, fmt.Errorf("Failed to get Absolute path of plant (path:%s), error: %s", expandedPlantPath, err)
+	}
+	vars, err := gardenMap.CollectAllVarsForPlant(plantID)
+	if err != nil {
+		return GardenTemplatePlantModel{}, err
+	}
+
+	return GardenTemplatePlantModel{
+		ID:   plantID,
+		Path: absPlantPath,
+		Vars: vars,
+	}, nil
+}
+
+// gardenMapTemplateFunctions ...
+//  template functions to look up other plants of the garden
+func gardenMapTemplateFunctions(gardenMap config.GardenMapModel) template.FuncMap {
+	return template.FuncMap{
+		"plant": func(plantID string) (GardenTemplatePlantModel, error) {
+			return createTemplatePlantModel(gardenMap, plantID)
+		},
+		"plantsInZone": func(zone string) ([]GardenTemplatePlantModel, error) {
+			plantIDs := gardenMap.FilteredPlantsIDs("", zone)
+			if _, isFound := gardenMap.Zones[zone]; !isFound && len(plantIDs) == 0 {
+				return []GardenTemplatePlantModel{}, fmt.Errorf("No zone found with ID: %s", zone)
+			}
+			sort.Strings(plantIDs)
+
+			plants := []GardenTemplatePlantModel{}
+			for _, aPlantID := range plantIDs {
+				aPlant, err := createTemplatePlantModel(gardenMap, aPlantID)
+				if err != nil {
+					return []GardenTemplatePlantModel{}, err
+				}
+				plants = append(plants, aPlant)
+			}
+			return plants, nil
+		},
+	}
+}
+
+// splitIntoWords ...
+//  splits the input into words, at every non letter/digit character
+//  and at lower->upper case changes, e.g.:
+//  "myApp-name" -> ["my", "App", "name"], "HTTPServer" -> ["HTTP", "Server"]
+func splitIntoWords(s string) []string {
+	words := []string{}
+	runes := []rune(s)
+	currentWord := []rune{}
+	flushWord := func() {
+		if len(currentWord) > 0 {
+			words = append(words, string(currentWord))
+			currentWord = []rune{}
+		}
+	}
+
+	for idx, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flushWord()
+			continue
+		}
+		if unicode.IsUpper(r) && len(currentWord) > 0 {
+			prev := currentWord[len(currentWord)-1]
+			isNextLower := idx+1 < len(runes) && unicode.IsLower(runes[idx+1])
+			if !unicode.IsUpper(prev) || isNextLower {
+				flushWord()
+			}
+		}
+		currentWord = append(currentWord, r)
+	}
+	flushWord()
+
+	return words
+}
+
+func upperFirst(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func toTitleCase(s string) string {
+	isWordStart := true
+	runes := []rune(s)
+	for idx, r := range runes {
+		if unicode.IsSpace(r) {
+			isWordStart = true
+			continue
+		}
+		if isWordStart {
+			runes[idx] = unicode.ToUpper(r)
+		}
+		isWordStart = false
+	}
+	return string(runes)
+}
+
+func toPascalCase(s string) string {
+	words := splitIntoWords(s)
+	for idx, aWord := range words {
+		words[idx] = upperFirst(strings.ToLower(aWord))
+	}
+	return strings.Join(words, "")
+}
+
+func toCamelCase(s string) string {
+	words := splitIntoWords(s)
+	for idx, aWord := range words {
+		if idx == 0 {
+			words[idx] = strings.ToLower(aWord)
+		} else {
+			words[idx] = upperFirst(strings.ToLower(aWord))
+		}
+	}
+	return strings.Join(words, "")
+}
+
+func toSnakeCase(s string) string {
+	return strings.ToLower(strings.Join(splitIntoWords(s), "_"))
+}
+
+func toKebabCase(s string) string {
+	return strings.ToLower(strings.Join(splitIntoWords(s), "-"))
+}
+
+// indentLines ...
+//  indents every line of the input with the given number of spaces
+func indentLines(spaces int, s string) string {
+	padding := strings.Repeat(" ", spaces)
+	lines := strings.Split(s, "\n")
+	for idx, aLine := range lines {
+		// empty lines are not padded, to not leave trailing whitespace
+		if aLine != "" {
+			lines[idx] = padding + aLine
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// uuidURLNamespace : the RFC 4122 URL namespace (6ba7b811-9dad-11d1-80b4-00c04fd430c8)
+var uuidURLNamespace = []byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// nameBasedUUID ...
+//  the name based (version 5, SHA-1) UUID of the name, in the URL namespace:
+//  the same name always gives the same UUID, so the rendered output is stable
+func nameBasedUUID(name string) string {
+	hash := sha1.New()
+	hash.Write(uuidURLNamespace)
+	hash.Write([]byte(name))
+	b := hash.Sum(nil)[:16]
+	b[6] = (b[6] & 0x0f) | 0x50
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/templateutil"
+	"github.com/stretchr/testify/require"
+)
+
+func evaluateWithTemplateFunctions(templateContent string, inventory GardenTemplateInventoryModel) (string, error) {
+	return templateutil.EvaluateTemplateStringToString(templateContent, inventory,
+		createAvailableTemplateFunctions(inventory))
+}
+
+func requireTemplateEvaluatesTo(t *testing.T, expected, templateContent string, inventory GardenTemplateInventoryModel) {
+	evaluatedContent, err := evaluateWithTemplateFunctions(templateContent, inventory)
+	require.NoError(t, err, templateContent)
+	require.Equal(t, expected, evaluatedContent, templateContent)
+}
+
+func Test_splitIntoWords(t *testing.T) {
+	require.Equal(t, []string{"my", "App", "name"}, splitIntoWords("myApp-name"))
+	require.Equal(t, []string{"HTTP", "Server"}, splitIntoWords("HTTPServer"))
+	require.Equal(t, []string{"Build", "Number", "2"}, splitIntoWords("Build Number_2"))
+	require.Equal(t, []string{"v1", "API"}, splitIntoWords("v1API"))
+	require.Equal(t, []string{}, splitIntoWords(" - "))
+}
+
+func Test_standardTemplateFunctions_strings(t *testing.T) {
+	inventory := GardenTemplateInventoryModel{
+		Vars: map[string]string{
+			"AppName": "my-cool app",
+		},
+	}
+
+	t.Log("case conversion")
+	requireTemplateEvaluatesTo(t, "MY-COOL APP", `{{ var "AppName" | upper }}`, inventory)
+	requireTemplateEvaluatesTo(t, "abc", `{{ "ABC" | lower }}`, inventory)
+	requireTemplateEvaluatesTo(t, "My-cool App", `{{ var "AppName" | title }}`, inventory)
+	requireTemplateEvaluatesTo(t, "myCoolApp", `{{ var "AppName" | camelCase }}`, inventory)
+	requireTemplateEvaluatesTo(t, "MyCoolApp", `{{ var "AppName" | pascalCase }}`, inventory)
+	requireTemplateEvaluatesTo(t, "my_cool_app", `{{ var "AppName" | snakeCase }}`, inventory)
+	requireTemplateEvaluatesTo(t, "my-cool-app", `{{ "MyCoolApp" | kebabCase }}`, inventory)
+
+	t.Log("trim & replace")
+	requireTemplateEvaluatesTo(t, "a b", `{{ "  a b \n" | trim }}`, inventory)
+	requireTemplateEvaluatesTo(t, "app", `{{ "my-app" | trimPrefix "my-" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "my", `{{ "my-app" | trimSuffix "-app" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "my_cool_app", `{{ "my-cool-app" | replace "-" "_" }}`, inventory)
+
+	t.Log("split & join")
+	requireTemplateEvaluatesTo(t, "a|b|c", `{{ "a,b,c" | split "," | join "|" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "a b ", `{{ range split "," "a,b" }}{{ . }} {{ end }}`, inventory)
+
+	t.Log("indent & nindent")
+	requireTemplateEvaluatesTo(t, "  a\n  b", `{{ "a\nb" | indent 2 }}`, inventory)
+	requireTemplateEvaluatesTo(t, "key:\n  a\n  b", `key:{{ "a\nb" | nindent 2 }}`, inventory)
+	requireTemplateEvaluatesTo(t, "  a\n\n  b\n", `{{ "a\n\nb\n" | indent 2 }}`, inventory)
+}
+
+func Test_standardTemplateFunctions_values(t *testing.T) {
+	inventory := GardenTemplateInventoryModel{
+		Vars: map[string]string{
+			"MyKey1":   "my value 1",
+			"EmptyKey": "",
+		},
+	}
+
+	t.Log("default")
+	requireTemplateEvaluatesTo(t, "my value 1", `{{ var "MyKey1" | default "def" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "def", `{{ var "EmptyKey" | default "def" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "my value 1", `{{ optionalVar "MyKey1" | default "def" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "def", `{{ optionalVar "MissingKey" | default "def" }}`, inventory)
+
+	t.Log("required")
+	requireTemplateEvaluatesTo(t, "my value 1", `{{ var "MyKey1" | required "MyKey1 is required" }}`, inventory)
+	_, err := evaluateWithTemplateFunctions(`{{ var "EmptyKey" | required "EmptyKey has to be set" }}`, inventory)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error calling required: EmptyKey has to be set")
+	_, err = evaluateWithTemplateFunctions(`{{ optionalVar "MissingKey" | required "MissingKey has to be set" }}`, inventory)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error calling required: MissingKey has to be set")
+
+	t.Log("optionalVar")
+	requireTemplateEvaluatesTo(t, "my value 1", `{{ optionalVar "MyKey1" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "", `{{ optionalVar "MissingKey" }}`, inventory)
+
+	t.Log("hasVar")
+	requireTemplateEvaluatesTo(t, "true", `{{ hasVar "EmptyKey" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "false", `{{ hasVar "MissingKey" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "no", `{{ if hasVar "MissingKey" }}{{ var "MissingKey" }}{{ else }}no{{ end }}`, inventory)
+
+	t.Log("env")
+	require.NoError(t, os.Setenv("GARDEN_TEST_ENV_KEY", "env value"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("GARDEN_TEST_ENV_KEY"))
+	}()
+	requireTemplateEvaluatesTo(t, "env value", `{{ env "GARDEN_TEST_ENV_KEY" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "", `{{ env "GARDEN_TEST_ENV_KEY_NOT_SET" }}`, inventory)
+}
+
+func Test_standardTemplateFunctions_paths(t *testing.T) {
+	inventory := GardenTemplateInventoryModel{
+		PlantPath: "/plants/apple-1",
+	}
+
+	requireTemplateEvaluatesTo(t, "/plants/apple-1/config/app.yml", `{{ pathJoin .PlantPath "config" "app.yml" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "apple-1", `{{ pathBase .PlantPath }}`, inventory)
+	requireTemplateEvaluatesTo(t, "/plants", `{{ pathDir .PlantPath }}`, inventory)
+}
+
+func Test_standardTemplateFunctions_serialization(t *testing.T) {
+	inventory := GardenTemplateInventoryModel{
+		Vars: map[string]string{
+			"B": "2",
+			"A": "1",
+		},
+	}
+
+	requireTemplateEvaluatesTo(t, `{"A":"1","B":"2"}`, `{{ toJSON .Vars }}`, inventory)
+	requireTemplateEvaluatesTo(t, `["a","b"]`, `{{ split "," "a,b" | toJSON }}`, inventory)
+	requireTemplateEvaluatesTo(t, "A: \"1\"\nB: \"2\"", `{{ toYAML .Vars }}`, inventory)
+	requireTemplateEvaluatesTo(t, "vars:\n  A: \"1\"\n  B: \"2\"", `vars:{{ toYAML .Vars | nindent 2 }}`, inventory)
+}
+
+func Test_standardTemplateFunctions_regex(t *testing.T) {
+	inventory := GardenTemplateInventoryModel{}
+
+	requireTemplateEvaluatesTo(t, "true", `{{ regexMatch "^v[0-9]+$" "v12" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "false", `{{ regexMatch "^v[0-9]+$" "12" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "com.example.my_app", `{{ "com.example.my-app" | regexReplaceAll "[^a-z.]" "_" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "1.2", `{{ "v1.2.3" | regexReplaceAll "^v([0-9]+)\\.([0-9]+).*$" "${1}.${2}" }}`, inventory)
+
+	_, err := evaluateWithTemplateFunctions(`{{ regexMatch "[" "a" }}`, inventory)
+	require.Error(t, err)
+}
+
+func Test_standardTemplateFunctions_encoding(t *testing.T) {
+	inventory := GardenTemplateInventoryModel{}
+
+	requireTemplateEvaluatesTo(t, "aGVsbG8=", `{{ "hello" | b64enc }}`, inventory)
+	requireTemplateEvaluatesTo(t, "hello", `{{ "aGVsbG8=" | b64dec }}`, inventory)
+	_, err := evaluateWithTemplateFunctions(`{{ "not base64!" | b64dec }}`, inventory)
+	require.Error(t, err)
+
+	requireTemplateEvaluatesTo(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", `{{ "hello" | sha256sum }}`, inventory)
+
+	t.Log("uuid - name based v5 UUIDs, the same for the same name")
+	requireTemplateEvaluatesTo(t, "6a7064dd-6a5a-5ee5-ad72-0ba7811e7f79", `{{ uuid "my-app" }}`, inventory)
+	requireTemplateEvaluatesTo(t, "6a7064dd-6a5a-5ee5-ad72-0ba7811e7f79", `{{ "my-app" | uuid }}`, inventory)
+	otherUUID, err := evaluateWithTemplateFunctions(`{{ uuid "my-other-app" }}`, inventory)
+	require.NoError(t, err)
+	require.NotEqual(t, "6a7064dd-6a5a-5ee5-ad72-0ba7811e7f79", otherUUID)
+}
+
+func Test_gardenMapTemplateFunctions(t *testing.T) {
+	gardenMap, _, err := loadTestGardenMap()
+	require.NoError(t, err)
+	evalContext := templateEvaluationContextModel{GardenMap: gardenMap}
+	absApplePath, err := pathutil.AbsPath("PLANTROOT/apple-1-dir")
+	require.NoError(t, err)
+
+	t.Log("plant")
+	evaluatedContent, err := evaluateTemplateString("test",
+		`{{ with plant "apple-1" }}{{ .ID }} | {{ .Path }} | {{ .Vars.IsApples }}{{ end }}`, evalContext)
+	require.NoError(t, err)
+	require.Equal(t, "apple-1 | "+absApplePath+" | yes", evaluatedContent)
+
+	t.Log("plant - unknown plant")
+	_, err = evaluateTemplateString("test", `{{ (plant "pear-1").Path }}`, evalContext)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "No plant found with ID: pear-1")
+
+	t.Log("plantsInZone - ordered by plant ID")
+	evaluatedContent, err = evaluateTemplateString("test",
+		`{{ range plantsInZone "fruits" }}{{ .ID }}:{{ .Vars.IsApples }} {{ end }}`, evalContext)
+	require.NoError(t, err)
+	require.Equal(t, "apple-1:yes orange-1:no ", evaluatedContent)
+
+	evaluatedContent, err = evaluateTemplateString("test",
+		`{{ range plantsInZone "oranges" }}{{ .ID }}{{ end }}`, evalContext)
+	require.NoError(t, err)
+	require.Equal(t, "orange-1", evaluatedContent)
+
+	t.Log("plantsInZone - unknown zone")
+	_, err = evaluateTemplateString("test", `{{ plantsInZone "vegetables" }}`, evalContext)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "No zone found with ID: vegetables")
+}
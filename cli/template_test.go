@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_loadTemplatePartials(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	sharedDir := filepath.Join(tmpDir, "shared")
+	seedDir := filepath.Join(tmpDir, "seed")
+	require.NoError(t, os.MkdirAll(sharedDir, 0777))
+	require.NoError(t, os.MkdirAll(seedDir, 0777))
+
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(sharedDir, "header.tmpl"),
+		`{{ define "header" }}# header of {{ .PlantID }}{{ end }}`))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(seedDir, "lanes.tmpl"),
+		`{{ define "lane" }}lane: {{ var "LaneName" }}{{ end }}`))
+	// not a partial file
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(seedDir, "README.md"),
+		`{{ define "header" }}{{ end }}`))
+
+	t.Log("Partials from multiple dirs, non existing dir is skipped")
+	partials, err := loadTemplatePartials(sharedDir, seedDir, filepath.Join(tmpDir, "does-not-exist"))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(partials.Files))
+
+	inventory := GardenTemplateInventoryModel{
+		Vars:    map[string]string{"LaneName": "beta"},
+		PlantID: "p1",
+	}
+	evaluatedContent, err := evaluateTemplateString("test", `{{ template "header" . }}
+{{ template "lane" . }}`, templateEvaluationContextModel{Inventory: inventory, Partials: partials})
+	require.NoError(t, err)
+	require.Equal(t, "# header of p1\nlane: beta", evaluatedContent)
+
+	t.Log("Name collision between partials")
+	collidingPth := filepath.Join(seedDir, "other.tmpl")
+	require.NoError(t, fileutil.WriteStringToFile(collidingPth,
+		`{{ define "header" }}other header{{ end }}`))
+	_, err = loadTemplatePartials(sharedDir, seedDir)
+	require.EqualError(t, err,
+		fmt.Sprintf(`Template partial "header" is defined in multiple files: %s and %s`,
+			filepath.Join(sharedDir, "header.tmpl"), collidingPth))
+}
+
+func Test_evaluateTemplateString_delimiters(t *testing.T) {
+	inventory := GardenTemplateInventoryModel{
+		Vars: map[string]string{"AppName": "my-app"},
+	}
+
+	t.Log("Delimiters of the evaluation context (e.g. from seed config)")
+	evaluatedContent, err := evaluateTemplateString("test", `name: [[ var "AppName" ]]
+image: {{ .Values.image }}`, templateEvaluationContextModel{
+		Inventory:  inventory,
+		Delimiters: templateDelimitersModel{Left: "[[", Right: "]]"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "name: my-app\nimage: {{ .Values.image }}", evaluatedContent)
+
+	t.Log("Per-file directive - overwrites the context's delimiters, and is removed from the output")
+	evaluatedContent, err = evaluateTemplateString("test", `# garden:delims <% %>
+name: <% var "AppName" %> [[ not evaluated ]]
+run: echo ${{ github.sha }}`, templateEvaluationContextModel{
+		Inventory:  inventory,
+		Delimiters: templateDelimitersModel{Left: "[[", Right: "]]"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "name: my-app [[ not evaluated ]]\nrun: echo ${{ github.sha }}", evaluatedContent)
+
+	t.Log("The directive is only checked in the first line")
+	evaluatedContent, err = evaluateTemplateString("test", `first line
+# garden:delims [[ ]]
+{{ var "AppName" }}`, templateEvaluationContextModel{Inventory: inventory})
+	require.NoError(t, err)
+	require.Equal(t, "first line\n# garden:delims [[ ]]\nmy-app", evaluatedContent)
+
+	t.Log("The directive has to be a comment only line")
+	evaluatedContent, err = evaluateTemplateString("test", `name: {{ var "AppName" }} # garden:delims [[ ]]`, templateEvaluationContextModel{Inventory: inventory})
+	require.NoError(t, err)
+	require.Equal(t, "name: my-app # garden:delims [[ ]]", evaluatedContent)
+
+	t.Log("Partials keep their own delimiters")
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "default.tmpl"),
+		`{{ define "default-delims" }}app: {{ var "AppName" }}{{ end }}`))
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(tmpDir, "custom.tmpl"),
+		`{{/* garden:delims [[ ]] */}}
+[[ define "custom-delims" ]]{{ .Values }}[[ end ]]`))
+	partials, err := loadTemplatePartials(tmpDir)
+	require.NoError(t, err)
+	evaluatedContent, err = evaluateTemplateString("test", `[[ template "default-delims" . ]] / [[ template "custom-delims" . ]]`,
+		templateEvaluationContextModel{
+			Inventory:  inventory,
+			Partials:   partials,
+			Delimiters: templateDelimitersModel{Left: "[[", Right: "]]"},
+		})
+	require.NoError(t, err)
+	require.Equal(t, "app: my-app / {{ .Values }}", evaluatedContent)
+}
+
+func Test_extractDelimitersDirective(t *testing.T) {
+	for firstLine, expectedDelimiters := range map[string]templateDelimitersModel{
+		"# garden:delims [[ ]]":             templateDelimitersModel{Left: "[[", Right: "]]"},
+		"  // garden:delims <% %>  ":        templateDelimitersModel{Left: "<%", Right: "%>"},
+		"{{/* garden:delims [[ ]] */}}":     templateDelimitersModel{Left: "[[", Right: "]]"},
+		"{{- /* garden:delims [[ ]] */ -}}": templateDelimitersModel{Left: "[[", Right: "]]"},
+		"<!-- garden:delims [[ ]] -->":      templateDelimitersModel{Left: "[[", Right: "]]"},
+		"/* garden:delims [[ ]] */":         templateDelimitersModel{Left: "[[", Right: "]]"},
+	} {
+		delimiters, content, isFound := extractDelimitersDirective(firstLine + "\nrest")
+		require.True(t, isFound, firstLine)
+		require.Equal(t, expectedDelimiters, delimiters, firstLine)
+		require.Equal(t, "rest", content, firstLine)
+	}
+
+	t.Log("Not a directive")
+	for _, aFirstLine := range []string{
+		// the comment's closer is not separated from the right delimiter
+		"{{/* garden:delims [[ ]]*/}}",
+		// not closed block comment
+		"{{/* garden:delims [[ ]]",
+		// not a comment only line
+		"name: x # garden:delims [[ ]]",
+		"garden:delims [[ ]]",
+		"# garden:delims [[ ]] and more",
+	} {
+		_, content, isFound := extractDelimitersDirective(aFirstLine + "\nrest")
+		require.False(t, isFound, aFirstLine)
+		require.Equal(t, aFirstLine+"\nrest", content, aFirstLine)
+	}
+}
+
+func Test_evaluateTemplateString_strict(t *testing.T) {
+	inventory := GardenTemplateInventoryModel{
+		Vars: map[string]string{"AppName": "my-app"},
+	}
+	partials := templatePartialsModel{Files: []templatePartialFileModel{
+		{Path: "partial.tmpl", Content: `{{ define "missing" }}{{ .Vars.Missing }}{{ end }}`},
+	}}
+
+	t.Log("Not strict - missing var renders <no value>")
+	evaluatedContent, err := evaluateTemplateString("test", `{{ .Vars.AppName }} {{ .Vars.Missing }}`,
+		templateEvaluationContextModel{Inventory: inventory})
+	require.NoError(t, err)
+	require.Equal(t, "my-app <no value>", evaluatedContent)
+
+	t.Log("Strict")
+	evalContext := templateEvaluationContextModel{Inventory: inventory, Partials: partials, IsStrict: true}
+	evaluatedContent, err = evaluateTemplateString("test", `{{ .Vars.AppName }}`, evalContext)
+	require.NoError(t, err)
+	require.Equal(t, "my-app", evaluatedContent)
+
+	_, err = evaluateTemplateString("test", `{{ .Vars.AppName }} {{ .Vars.Missing }}`, evalContext)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `map has no entry for key "Missing"`)
+
+	t.Log("Strict - in a partial")
+	_, err = evaluateTemplateString("test", `{{ template "missing" . }}`, evalContext)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `map has no entry for key "Missing"`)
+}
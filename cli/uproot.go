@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/colorstring"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/codegangsta/cli"
+)
+
+// recordedFilesModel ...
+//  the files garden wrote into a plant (recorded in the garden state),
+//  by their current state in the plant dir
+type recordedFilesModel struct {
+	// Unchanged : the files which are the same as garden wrote them,
+	//  those can be removed
+	Unchanged []string
+	// Edited : the files which were edited locally since garden wrote them,
+	//  those are kept
+	Edited []string
+	// Missing : the files which were already removed from the plant
+	Missing []string
+}
+
+// classifyRecordedFiles ...
+//  relPths are slash separated, plant root relative paths,
+//  the returned lists are sorted
+func classifyRecordedFiles(plantDirPth string, plantState config.PlantStateModel, relPths []string) (recordedFilesModel, error) {
+	recordedFiles := recordedFilesModel{Unchanged: []string{}, Edited: []string{}, Missing: []string{}}
+	sortedPths := append([]string{}, relPths...)
+	sort.Strings(sortedPths)
+	for _, aPth := range sortedPths {
+		plantFilePth := filepath.Join(plantDirPth, filepath.FromSlash(aPth))
+		fileInfo, err := os.Lstat(plantFilePth)
+		if os.IsNotExist(err) {
+			recordedFiles.Missing = append(recordedFiles.Missing, aPth)
+			continue
+		} else if err != nil {
+			return recordedFilesModel{}, err
+		}
+		if !fileInfo.Mode().IsRegular() {
+			// garden only records regular files
+			recordedFiles.Edited = append(recordedFiles.Edited, aPth)
+			continue
+		}
+		currentHash, err := fileSHA256(plantFilePth)
+		if err != nil {
+			return recordedFilesModel{}, err
+		}
+		if currentHash != plantState.Files[aPth] {
+			recordedFiles.Edited = append(recordedFiles.Edited, aPth)
+			continue
+		}
+		recordedFiles.Unchanged = append(recordedFiles.Unchanged, aPth)
+	}
+	return recordedFiles, nil
+}
+
+// removeEmptyParentDirs ...
+//  removes the parent dirs of the path which are empty,
+//  up to (but not including) the stop dir
+func removeEmptyParentDirs(pth, stopDirPth string, transaction *plantTransactionModel) error {
+	for dirPth := filepath.Dir(pth); dirPth != stopDirPth && dirPth != filepath.Dir(dirPth); dirPth = filepath.Dir(dirPth) {
+		fileInfo, err := os.Lstat(dirPth)
+		if err != nil {
+			return err
+		}
+		if isNonEmpty, err := isNonEmptyDir(dirPth, fileInfo); err != nil {
+			return err
+		} else if isNonEmpty {
+			return nil
+		}
+		if err := transaction.save(dirPth); err != nil {
+			return err
+		}
+		if err := os.Remove(dirPth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeEmptyDir ...
+//  removes the dir if it exists and it's empty
+func removeEmptyDir(dirPth string) error {
+	fileInfo, err := os.Lstat(dirPth)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if isNonEmpty, err := isNonEmptyDir(dirPth, fileInfo); err != nil || isNonEmpty || !fileInfo.IsDir() {
+		return err
+	}
+	return os.Remove(dirPth)
+}
+
+// removePlantFiles ...
+//  removes the files (slash separated, plant root relative paths)
+//  and their merge bases, and the dirs which end up empty.
+//  The plant dir itself is kept. The removed paths are saved
+//  into the transaction (if it's not nil) first.
+func removePlantFiles(plantDirPth, mergeBasesDirPth string, relPths []string, transaction *plantTransactionModel, logger *log.Logger) error {
+	for _, aPth := range relPths {
+		plantFilePth := filepath.Join(plantDirPth, filepath.FromSlash(aPth))
+		logger.Infof("    removed      %s", plantFilePth)
+		if err := transaction.save(plantFilePth); err != nil {
+			return err
+		}
+		if err := os.Remove(plantFilePth); err != nil {
+			return err
+		}
+		if err := removeEmptyParentDirs(plantFilePth, plantDirPth, transaction); err != nil {
+			return fmt.Errorf("Failed to remove the empty dirs of file (%s), error: %s", aPth, err)
+		}
+
+		basePth := filepath.Join(mergeBasesDirPth, filepath.FromSlash(aPth))
+		if err := transaction.save(basePth); err != nil {
+			return err
+		}
+		if err := os.Remove(basePth); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := removeEmptyParentDirs(basePth, mergeBasesDirPth, transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneCandidatePaths ...
+//  the files a previous grow wrote into the plant, but the current
+//  render doesn't produce; the files skipped because of a conflict
+//  are still produced by the seed
+func pruneCandidatePaths(plantState config.PlantStateModel, renderedFileHashes map[string]string, conflicts []fileConflictModel) []string {
+	skippedPths := map[string]bool{}
+	for _, aConflict := range conflicts {
+		if aConflict.Policy == config.ConflictPolicySkip {
+			skippedPths[aConflict.Path] = true
+		}
+	}
+
+	candidatePths := []string{}
+	for aPth := range plantState.Files {
+		if _, isRendered := renderedFileHashes[aPth]; !isRendered && !skippedPths[aPth] {
+			candidatePths = append(candidatePths, aPth)
+		}
+	}
+	sort.Strings(candidatePths)
+	return candidatePths
+}
+
+// prunePlant ...
+//  removes the files a previous grow wrote, but the seed no longer
+//  produces (the locally edited ones are kept), after confirmation.
+//  In a dry run the files which would be removed are only listed.
+func prunePlant(plantID, plantDirPth, mergeBasesDirPth string, plantState config.PlantStateModel, candidatePths []string, options growOptionsModel, transaction *plantTransactionModel, logger *log.Logger) error {
+	if len(candidatePths) < 1 {
+		return nil
+	}
+	logger.Println("--> Pruning the files the seed no longer produces ...")
+	recordedFiles, err := classifyRecordedFiles(plantDirPth, plantState, candidatePths)
+	if err != nil {
+		return err
+	}
+	for _, aPth := range recordedFiles.Edited {
+		logger.Warnf(" -> %s was edited locally, it's kept", aPth)
+	}
+
+	if options.IsDryRun {
+		for _, aPth := range recordedFiles.Unchanged {
+			logger.Infof("    would be %-12s %s", "removed", filepath.Join(plantDirPth, filepath.FromSlash(aPth)))
+		}
+		return nil
+	}
+
+	for _, aPth := range recordedFiles.Missing {
+		delete(plantState.Files, aPth)
+	}
+	if len(recordedFiles.Unchanged) < 1 {
+		return nil
+	}
+	if options.Confirm != nil {
+		for _, aPth := range recordedFiles.Unchanged {
+			logger.Infof("    %s", filepath.Join(plantDirPth, filepath.FromSlash(aPth)))
+		}
+		isConfirmed, err := options.Confirm(fmt.Sprintf("Remove %d file(s) of plant (%s), which the seed no longer produces?", len(recordedFiles.Unchanged), plantID))
+		if err != nil {
+			return err
+		}
+		if !isConfirmed {
+			logger.Warnln(" -> Not confirmed, the files are kept")
+			return nil
+		}
+	}
+
+	if err := removePlantFiles(plantDirPth, mergeBasesDirPth, recordedFiles.Unchanged, transaction, logger); err != nil {
+		return err
+	}
+	for _, aPth := range recordedFiles.Unchanged {
+		delete(plantState.Files, aPth)
+	}
+	return nil
+}
+
+// uprootOptionsModel ...
+type uprootOptionsModel struct {
+	// Confirm : asks for confirmation before removing anything,
+	//  nothing is asked if it's nil
+	Confirm confirmFunc
+}
+
+// uprootPlants ...
+//  removes the files garden wrote into the plants (recorded in the garden
+//  state), and the dirs which end up empty (the plant's dir too).
+//  The locally edited files, and the files garden didn't write are kept.
+//  The plants are removed from the garden state.
+func uprootPlants(gardenDirAbsPth string, gardenMap config.GardenMapModel, plantIDs []string, options uprootOptionsModel) error {
+	gardenState, err := config.LoadGardenState(gardenDirAbsPth)
+	if err != nil {
+		return fmt.Errorf("Failed to load garden state, error: %s", err)
+	}
+
+	plantDirPths := map[string]string{}
+	plantRecordedFiles := map[string]recordedFilesModel{}
+	removableCount := 0
+	for _, aPlantID := range plantIDs {
+		plantModel, isFound := gardenMap.Plants[aPlantID]
+		if !isFound {
+			return fmt.Errorf("Can't find Plant with ID: %s", aPlantID)
+		}
+		expandedPlantPath := plantModel.ExpandedPath(aPlantID)
+		plantDirPth, err := pathutil.AbsPath(expandedPlantPath)
+		if err != nil {
+			return fmt.Errorf("Failed to get Absolute path of plant (path:%s), error: %s", expandedPlantPath, err)
+		}
+		plantState := gardenState.PlantState(aPlantID)
+		recordedPths := []string{}
+		for aPth := range plantState.Files {
+			recordedPths = append(recordedPths, aPth)
+		}
+		recordedFiles, err := classifyRecordedFiles(plantDirPth, plantState, recordedPths)
+		if err != nil {
+			return fmt.Errorf("Failed to check the files of plant (%s), error: %s", aPlantID, err)
+		}
+		plantDirPths[aPlantID] = plantDirPth
+		plantRecordedFiles[aPlantID] = recordedFiles
+		removableCount += len(recordedFiles.Unchanged)
+
+		fmt.Println()
+		log.Infof("%s %s (%s)", colorstring.Yellow("==> plant:"), colorstring.Green(aPlantID), plantDirPth)
+		if len(plantState.Files) < 1 {
+			log.Infoln("    no recorded files, the plant was not grown")
+		}
+		for _, aPth := range recordedFiles.Unchanged {
+			log.Infof("    %-12s %s", "remove", aPth)
+		}
+		for _, aPth := range recordedFiles.Edited {
+			log.Warnf("    %-12s %s (edited locally)", "keep", aPth)
+		}
+	}
+	fmt.Println()
+
+	if removableCount > 0 && options.Confirm != nil {
+		isConfirmed, err := options.Confirm(fmt.Sprintf("Remove %d file(s) of %d plant(s)?", removableCount, len(plantIDs)))
+		if err != nil {
+			return err
+		}
+		if !isConfirmed {
+			log.Warnln("Not confirmed, nothing was removed")
+			return nil
+		}
+	}
+
+	var uprootErr error
+	for _, aPlantID := range plantIDs {
+		plantDirPth := plantDirPths[aPlantID]
+		mergeBasesDirPth := config.PlantMergeBasesDirPath(gardenDirAbsPth, aPlantID)
+		log.Infof("==> uprooting plant: %s", aPlantID)
+		if err := removePlantFiles(plantDirPth, mergeBasesDirPth, plantRecordedFiles[aPlantID].Unchanged, nil, log.StandardLogger()); err != nil {
+			uprootErr = fmt.Errorf("Failed to uproot plant (%s), error: %s", aPlantID, err)
+			break
+		}
+		if err := os.RemoveAll(mergeBasesDirPth); err != nil {
+			uprootErr = fmt.Errorf("Failed to remove the merge bases of plant (%s), error: %s", aPlantID, err)
+			break
+		}
+		if err := removeEmptyDir(plantDirPth); err != nil {
+			uprootErr = fmt.Errorf("Failed to remove the dir of plant (%s), error: %s", aPlantID, err)
+			break
+		}
+		delete(gardenState.Plants, aPlantID)
+	}
+
+	// the plants uprooted before an error are recorded too
+	if err := config.SaveGardenState(gardenDirAbsPth, gardenState); err != nil {
+		log.Errorf("Failed to save garden state, error: %s", err)
+	}
+	return uprootErr
+}
+
+func uproot(c *cli.Context) {
+	log.Infoln("Uproot")
+
+	gardenMap, gardenDirAbsPth, err := config.LoadGardenMap("")
+	if err != nil {
+		log.Fatalf("Failed to load Garden Map: %s", err)
+	}
+
+	plantIDs := gardenMap.FilteredPlantsIDs(WorkWithPlantID, WorkWithZone)
+	if len(plantIDs) < 1 {
+		log.Fatalln("No plants to uproot!")
+	}
+	options := uprootOptionsModel{}
+	if !c.Bool(YesKey) {
+		options.Confirm = askForConfirmation
+	}
+	if err := uprootPlants(gardenDirAbsPth, gardenMap, plantIDs, options); err != nil {
+		log.Fatalf("Failed to uproot plants: %s", err)
+	}
+}
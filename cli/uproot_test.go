@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/bitrise-io/garden/config"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func testPathExists(t *testing.T, pth string, isExpected bool) {
+	isExist, err := pathutil.IsPathExists(pth)
+	require.NoError(t, err)
+	require.Equal(t, isExpected, isExist, pth)
+}
+
+func Test_growPlants_prune(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	seedDirPth := filepath.Join(gardenDirPth, "seeds", "app")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "readme\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "docs", "guide", "old.md"), "old\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "docs", "edited.md"), "edited\n", 0644)
+
+	plantDirPth := filepath.Join(tmpDir, "plant")
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app-1": config.PlantModel{Path: plantDirPth, Seed: "app"},
+		},
+	}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+
+	t.Log("Without --prune the files the seed no longer produces are kept")
+	require.NoError(t, os.RemoveAll(filepath.Join(seedDirPth, "docs")))
+	writeTestFile(t, filepath.Join(plantDirPth, "docs", "edited.md"), "edited locally\n", 0644)
+	writeTestFile(t, filepath.Join(plantDirPth, "docs", "local.md"), "local\n", 0644)
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{}))
+	testPathExists(t, filepath.Join(plantDirPth, "docs", "guide", "old.md"), true)
+
+	t.Log("Dry run - nothing is removed, nothing is asked")
+	confirmQuestions := []string{}
+	declineConfirm := func(question string) (bool, error) {
+		confirmQuestions = append(confirmQuestions, question)
+		return false, nil
+	}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsDryRun: true, IsPrune: true, Confirm: declineConfirm}))
+	testPathExists(t, filepath.Join(plantDirPth, "docs", "guide", "old.md"), true)
+	require.Equal(t, []string{}, confirmQuestions)
+
+	t.Log("Not confirmed - nothing is removed")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsPrune: true, Confirm: declineConfirm}))
+	testPathExists(t, filepath.Join(plantDirPth, "docs", "guide", "old.md"), true)
+	require.Equal(t, []string{"Remove 1 file(s) of plant (app-1), which the seed no longer produces?"}, confirmQuestions)
+
+	t.Log("Prune - the unchanged files and the dirs which end up empty are removed")
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1"}, growOptionsModel{IsPrune: true}))
+	testPathExists(t, filepath.Join(plantDirPth, "docs", "guide"), false)
+	testFileContent(t, filepath.Join(plantDirPth, "docs", "edited.md"), "edited locally\n")
+	testFileContent(t, filepath.Join(plantDirPth, "docs", "local.md"), "local\n")
+	testFileContent(t, filepath.Join(plantDirPth, "README.md"), "readme\n")
+
+	gardenState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+	recordedPths := []string{}
+	for aPth := range gardenState.PlantState("app-1").Files {
+		recordedPths = append(recordedPths, aPth)
+	}
+	sort.Strings(recordedPths)
+	require.Equal(t, []string{"README.md", "docs/edited.md"}, recordedPths)
+}
+
+func Test_uprootPlants(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, "garden")
+	seedDirPth := filepath.Join(gardenDirPth, "seeds", "app")
+	writeTestFile(t, filepath.Join(seedDirPth, "README.md"), "readme\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "src", "main.go"), "package main\n", 0644)
+	writeTestFile(t, filepath.Join(seedDirPth, "config", "app.yml"), "name: app\n", 0644)
+
+	gardenMap := config.GardenMapModel{
+		Plants: map[string]config.PlantModel{
+			"app-1": config.PlantModel{Path: filepath.Join(tmpDir, "app-1"), Seed: "app"},
+			"app-2": config.PlantModel{Path: filepath.Join(tmpDir, "app-2"), Seed: "app"},
+			"app-3": config.PlantModel{Path: filepath.Join(tmpDir, "app-3"), Seed: "app"},
+		},
+	}
+	require.NoError(t, growPlants(gardenDirPth, gardenMap, []string{"app-1", "app-2", "app-3"}, growOptionsModel{}))
+	writeTestFile(t, filepath.Join(tmpDir, "app-1", "config", "app.yml"), "name: edited\n", 0644)
+	writeTestFile(t, filepath.Join(tmpDir, "app-1", "src", "local.go"), "package main\n", 0644)
+
+	t.Log("Not confirmed - nothing is removed")
+	confirmQuestions := []string{}
+	declineConfirm := func(question string) (bool, error) {
+		confirmQuestions = append(confirmQuestions, question)
+		return false, nil
+	}
+	require.NoError(t, uprootPlants(gardenDirPth, gardenMap, []string{"app-1", "app-2"}, uprootOptionsModel{Confirm: declineConfirm}))
+	require.Equal(t, []string{"Remove 5 file(s) of 2 plant(s)?"}, confirmQuestions)
+	testFileContent(t, filepath.Join(tmpDir, "app-2", "README.md"), "readme\n")
+
+	t.Log("Uproot - the files garden created and didn't change are removed, with the empty dirs")
+	confirm := func(question string) (bool, error) {
+		return true, nil
+	}
+	require.NoError(t, uprootPlants(gardenDirPth, gardenMap, []string{"app-1", "app-2"}, uprootOptionsModel{Confirm: confirm}))
+	testPathExists(t, filepath.Join(tmpDir, "app-1", "README.md"), false)
+	testPathExists(t, filepath.Join(tmpDir, "app-1", "src", "main.go"), false)
+	testFileContent(t, filepath.Join(tmpDir, "app-1", "src", "local.go"), "package main\n")
+	testFileContent(t, filepath.Join(tmpDir, "app-1", "config", "app.yml"), "name: edited\n")
+	testPathExists(t, filepath.Join(tmpDir, "app-2"), false)
+	testFileContent(t, filepath.Join(tmpDir, "app-3", "README.md"), "readme\n")
+
+	gardenState, err := config.LoadGardenState(gardenDirPth)
+	require.NoError(t, err)
+	require.Equal(t, []string{"app-3"}, gardenState.PlantIDs())
+	testPathExists(t, config.PlantMergeBasesDirPath(gardenDirPth, "app-2"), false)
+	testPathExists(t, config.PlantMergeBasesDirPath(gardenDirPth, "app-3"), true)
+
+	t.Log("A plant which is not in the state - nothing to remove")
+	require.NoError(t, uprootPlants(gardenDirPth, gardenMap, []string{"app-2"}, uprootOptionsModel{Confirm: declineConfirm}))
+	require.Equal(t, 1, len(confirmQuestions))
+}
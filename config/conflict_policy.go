@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"path"
+)
+
+const (
+	// ConflictPolicyFail : the plant's grow fails, nothing is written (default)
+	ConflictPolicyFail = "fail"
+	// ConflictPolicySkip : the locally edited file is kept as it is
+	ConflictPolicySkip = "skip"
+	// ConflictPolicyBackup : the locally edited file is copied
+	//  to a backup file, then overwritten
+	ConflictPolicyBackup = "backup"
+	// ConflictPolicyForce : the locally edited file is overwritten
+	ConflictPolicyForce = "force"
+	// ConflictPolicyMerge : three-way merge of the local and the new version,
+	//  with the last rendered version as the merge base
+	ConflictPolicyMerge = "merge"
+)
+
+// ConflictPolicyModel ...
+//  what to do with a plant's file which was edited locally since
+//  garden last wrote it, and the seed would change it too
+type ConflictPolicyModel struct {
+	// Pattern : glob pattern, matched against the file's path,
+	//  relative to the plant's root (e.g. "*.md" or "config/*")
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Policy  string `json:"policy" yaml:"policy"`
+}
+
+// IsValidConflictPolicy ...
+func IsValidConflictPolicy(policy string) bool {
+	switch policy {
+	case ConflictPolicyFail, ConflictPolicySkip, ConflictPolicyBackup, ConflictPolicyForce, ConflictPolicyMerge:
+		return true
+	}
+	return false
+}
+
+// ConflictPolicyForPath ...
+//  returns the policy of the first ConflictPolicies item which matches
+//  the (slash separated, plant root relative) path, or defaultPolicy
+//  if none matches
+func (gardenMap GardenMapModel) ConflictPolicyForPath(relPth, defaultPolicy string) string {
+	for _, aConflictPolicy := range gardenMap.ConflictPolicies {
+		if isMatch, err := path.Match(aConflictPolicy.Pattern, relPth); err == nil && isMatch {
+			return aConflictPolicy.Policy
+		}
+	}
+	return defaultPolicy
+}
+
+// validateConflictPolicies ...
+func validateConflictPolicies(conflictPolicies []ConflictPolicyModel) error {
+	for _, aConflictPolicy := range conflictPolicies {
+		if _, err := path.Match(aConflictPolicy.Pattern, ""); err != nil {
+			return fmt.Errorf("Invalid conflict_policies pattern (%s), error: %s", aConflictPolicy.Pattern, err)
+		}
+		if !IsValidConflictPolicy(aConflictPolicy.Policy) {
+			return fmt.Errorf("Invalid conflict policy (%s) for pattern: %s", aConflictPolicy.Policy, aConflictPolicy.Pattern)
+		}
+	}
+	return nil
+}
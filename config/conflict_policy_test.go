@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GardenMapModel_ConflictPolicyForPath(t *testing.T) {
+	gardenMap := GardenMapModel{
+		ConflictPolicies: []ConflictPolicyModel{
+			{Pattern: "*.md", Policy: ConflictPolicySkip},
+			{Pattern: "config/*", Policy: ConflictPolicyBackup},
+			{Pattern: "*", Policy: ConflictPolicyForce},
+		},
+	}
+	require.Equal(t, ConflictPolicySkip, gardenMap.ConflictPolicyForPath("README.md", ConflictPolicyFail))
+	require.Equal(t, ConflictPolicyBackup, gardenMap.ConflictPolicyForPath("config/app.json", ConflictPolicyFail))
+	require.Equal(t, ConflictPolicyForce, gardenMap.ConflictPolicyForPath("main.go", ConflictPolicyFail))
+	require.Equal(t, ConflictPolicyFail, gardenMap.ConflictPolicyForPath("src/main.go", ConflictPolicyFail))
+	require.Equal(t, ConflictPolicySkip, GardenMapModel{}.ConflictPolicyForPath("main.go", ConflictPolicySkip))
+}
+
+func Test_validateConflictPolicies(t *testing.T) {
+	require.NoError(t, validateConflictPolicies([]ConflictPolicyModel{{Pattern: "*.md", Policy: ConflictPolicySkip}}))
+	require.NoError(t, validateConflictPolicies([]ConflictPolicyModel{{Pattern: "*.md", Policy: ConflictPolicyMerge}}))
+	require.EqualError(t, validateConflictPolicies([]ConflictPolicyModel{{Pattern: "*.md", Policy: "overwrite"}}),
+		"Invalid conflict policy (overwrite) for pattern: *.md")
+	require.Error(t, validateConflictPolicies([]ConflictPolicyModel{{Pattern: "[", Policy: ConflictPolicySkip}}))
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// GardenLockFileName : name of the lock file, in the garden dir
+	GardenLockFileName = "garden.lock"
+)
+
+// LockedSeedModel ...
+//  the resolved version of a remote seed
+type LockedSeedModel struct {
+	// Revision : git commit hash, or the archive's SHA256 checksum
+	Revision string `json:"revision" yaml:"revision"`
+	// ContentHash : hash of the seed directory's content
+	ContentHash string `json:"content_hash" yaml:"content_hash"`
+}
+
+// GardenLockModel ...
+//  the locked versions of the garden's remote seeds,
+//  by seed reference (the `seed` property of the plants)
+type GardenLockModel struct {
+	Seeds map[string]LockedSeedModel `json:"seeds" yaml:"seeds"`
+}
+
+// NewGardenLock ...
+func NewGardenLock() GardenLockModel {
+	return GardenLockModel{Seeds: map[string]LockedSeedModel{}}
+}
+
+// Copy ...
+func (gardenLock GardenLockModel) Copy() GardenLockModel {
+	copied := NewGardenLock()
+	for seed, lockedSeed := range gardenLock.Seeds {
+		copied.Seeds[seed] = lockedSeed
+	}
+	return copied
+}
+
+// StaleSeeds ...
+//  the seed references for which the lock is out of date compared to
+//  the garden map: locked seeds which are not used by any plant anymore,
+//  and archive seeds which have a different seed_sha256 in the map
+func (gardenLock GardenLockModel) StaleSeeds(gardenMap GardenMapModel) []string {
+	remoteSeeds := gardenMap.RemoteSeeds()
+	staleSeeds := []string{}
+	for seed, lockedSeed := range gardenLock.Seeds {
+		plantModel, isFound := remoteSeeds[seed]
+		if !isFound {
+			staleSeeds = append(staleSeeds, seed)
+			continue
+		}
+		seedRef, err := ParseSeedRef(seed)
+		if err == nil && seedRef.Source == SeedSourceArchive && lockedSeed.Revision != plantModel.SeedSHA256 {
+			staleSeeds = append(staleSeeds, seed)
+		}
+	}
+	sort.Strings(staleSeeds)
+	return staleSeeds
+}
+
+// GardenLockFilePath ...
+func GardenLockFilePath(gardenDirPth string) string {
+	return filepath.Join(gardenDirPth, GardenLockFileName)
+}
+
+// LoadGardenLock ...
+//  loads the garden dir's lock file.
+//  The lock file is optional, an empty lock is returned
+//  if the garden dir doesn't have one.
+func LoadGardenLock(gardenDirPth string) (GardenLockModel, error) {
+	lockPth := GardenLockFilePath(gardenDirPth)
+	isExist, err := pathutil.IsPathExists(lockPth)
+	if err != nil {
+		return GardenLockModel{}, err
+	}
+	if !isExist {
+		return NewGardenLock(), nil
+	}
+
+	fileBytes, err := fileutil.ReadBytesFromFile(lockPth)
+	if err != nil {
+		return GardenLockModel{}, err
+	}
+
+	var gardenLock GardenLockModel
+	if err := yaml.Unmarshal(fileBytes, &gardenLock); err != nil {
+		return GardenLockModel{}, fmt.Errorf("Failed to parse garden lock (path:%s), error: %s", lockPth, err)
+	}
+	if gardenLock.Seeds == nil {
+		gardenLock.Seeds = map[string]LockedSeedModel{}
+	}
+	return gardenLock, nil
+}
+
+// SaveGardenLock ...
+func SaveGardenLock(gardenDirPth string, gardenLock GardenLockModel) error {
+	fileBytes, err := yaml.Marshal(gardenLock)
+	if err != nil {
+		return err
+	}
+	lockPth := GardenLockFilePath(gardenDirPth)
+	if err := writeBytesToFileAtomically(lockPth, fileBytes); err != nil {
+		return fmt.Errorf("Failed to write garden lock (path:%s), error: %s", lockPth, err)
+	}
+	return nil
+}
+
+// writeBytesToFileAtomically ...
+//  writes the content into a temporary file next to the target
+//  (on the same file system), syncs it, then renames it over the target
+func writeBytesToFileAtomically(pth string, fileBytes []byte) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(pth), "."+filepath.Base(pth)+".tmp-")
+	if err != nil {
+		return err
+	}
+	tmpPth := tmpFile.Name()
+	_, writeErr := tmpFile.Write(fileBytes)
+	if writeErr == nil {
+		writeErr = tmpFile.Sync()
+	}
+	if err := tmpFile.Close(); err != nil && writeErr == nil {
+		writeErr = err
+	}
+	if writeErr == nil {
+		writeErr = os.Chmod(tmpPth, 0644)
+	}
+	if writeErr == nil {
+		writeErr = os.Rename(tmpPth, pth)
+	}
+	if writeErr != nil {
+		if err := os.Remove(tmpPth); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("%s (and failed to remove the temporary file: %s)", writeErr, err)
+		}
+		return writeErr
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadGardenLock(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("No lock file")
+	gardenLock, err := LoadGardenLock(tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, NewGardenLock(), gardenLock)
+
+	t.Log("Save and load")
+	gardenLock.Seeds["git+https://github.com/org/seeds.git//apples@v1.0.0"] = LockedSeedModel{Revision: "abc", ContentHash: "def"}
+	require.NoError(t, SaveGardenLock(tmpDir, gardenLock))
+	loadedLock, err := LoadGardenLock(tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, gardenLock, loadedLock)
+
+	t.Log("Written atomically - no temporary file is left")
+	fileInfos, err := ioutil.ReadDir(tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfos))
+	require.Equal(t, GardenLockFileName, fileInfos[0].Name())
+}
+
+func Test_GardenLockModel_StaleSeeds(t *testing.T) {
+	gardenMap := GardenMapModel{
+		Plants: map[string]PlantModel{
+			"local": PlantModel{Seed: "apples"},
+			"git-1": PlantModel{Seed: "git+https://github.com/org/seeds.git//apples@v2.0.0"},
+			"git-2": PlantModel{Seed: "git+https://github.com/org/seeds.git//apples@v2.0.0"},
+			"archive": PlantModel{
+				Seed:       "https://example.com/seeds.tar.gz",
+				SeedSHA256: "new-sha",
+			},
+		},
+	}
+	require.Equal(t, map[string]PlantModel{
+		"git+https://github.com/org/seeds.git//apples@v2.0.0": gardenMap.Plants["git-1"],
+		"https://example.com/seeds.tar.gz":                    gardenMap.Plants["archive"],
+	}, gardenMap.RemoteSeeds())
+
+	t.Log("Up to date - seeds which are not yet locked are not stale")
+	gardenLock := NewGardenLock()
+	gardenLock.Seeds["git+https://github.com/org/seeds.git//apples@v2.0.0"] = LockedSeedModel{Revision: "abc"}
+	require.Equal(t, []string{}, gardenLock.StaleSeeds(gardenMap))
+
+	t.Log("Seed not used anymore, and archive with a different checksum")
+	gardenLock.Seeds["git+https://github.com/org/seeds.git//apples@v1.0.0"] = LockedSeedModel{Revision: "abc"}
+	gardenLock.Seeds["https://example.com/seeds.tar.gz"] = LockedSeedModel{Revision: "old-sha"}
+	require.Equal(t, []string{
+		"git+https://github.com/org/seeds.git//apples@v1.0.0",
+		"https://example.com/seeds.tar.gz",
+	}, gardenLock.StaleSeeds(gardenMap))
+}
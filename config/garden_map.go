@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/bitrise-io/go-utils/colorstring"
@@ -19,10 +21,13 @@ type PlantVarsMap map[string]string
 
 // PlantModel ...
 type PlantModel struct {
-	Path  string       `json:"path" yaml:"path"`
-	Seed  string       `json:"seed" yaml:"seed"`
-	Vars  PlantVarsMap `json:"vars" yaml:"vars"`
-	Zones []string     `json:"zones" yaml:"zones"`
+	Path string `json:"path" yaml:"path"`
+	Seed string `json:"seed" yaml:"seed"`
+	// SeedSHA256 : the expected SHA256 checksum of the seed's archive,
+	//  required for archive seeds
+	SeedSHA256 string       `json:"seed_sha256,omitempty" yaml:"seed_sha256,omitempty"`
+	Vars       PlantVarsMap `json:"vars" yaml:"vars"`
+	Zones      []string     `json:"zones" yaml:"zones"`
 }
 
 // ZoneModel ..
@@ -35,8 +40,40 @@ type PlantsMap map[string]PlantModel
 
 // GardenMapModel ...
 type GardenMapModel struct {
-	Plants map[string]PlantModel `json:"plants" yaml:"plants"`
-	Zones  map[string]ZoneModel  `json:"zones" yaml:"zones"`
+	// StrictVars : if true, accessing a missing var in a template
+	//  (e.g. {{ .Vars.Missing }}) is an error, instead of rendering <no value>.
+	//  Not set in maps created before strict mode, which are not strict;
+	//  `garden init` creates new maps with strict_vars: true
+	StrictVars *bool `json:"strict_vars,omitempty" yaml:"strict_vars,omitempty"`
+	// ConflictPolicies : how to handle the locally edited files of the plants,
+	//  the first matching pattern's policy is used
+	ConflictPolicies []ConflictPolicyModel `json:"conflict_policies,omitempty" yaml:"conflict_policies,omitempty"`
+	// Snapshots : the retention of the plants' snapshots (see SnapshotsConfig)
+	Snapshots *SnapshotsConfigModel `json:"snapshots,omitempty" yaml:"snapshots,omitempty"`
+	Plants    map[string]PlantModel `json:"plants" yaml:"plants"`
+	Zones     map[string]ZoneModel  `json:"zones" yaml:"zones"`
+}
+
+const (
+	// GardenMapFileName : name of the garden map file, in the garden dir
+	GardenMapFileName = "map.yml"
+
+	newGardenMapContent = `# strict_vars: accessing a missing var in a template (e.g. {{ .Vars.Missing }})
+#  is an error, instead of rendering <no value> into the plant's file
+strict_vars: true
+plants: {}
+zones: {}
+`
+	// newGardenGitignoreContent : the garden dir's local directories
+	//  (the remote seed cache and the garden state) are not version controlled
+	newGardenGitignoreContent = `.cache/
+` + GardenStateDirName + `/
+`
+)
+
+// IsStrictVars ...
+func (gardenMap GardenMapModel) IsStrictVars() bool {
+	return gardenMap.StrictVars != nil && *gardenMap.StrictVars
 }
 
 // ExpandedPath ...
@@ -109,6 +146,31 @@ func (gardenMap GardenMapModel) FilteredPlantsIDs(plantID, zone string) []string
 	return ids
 }
 
+// RemoteSeeds ...
+//  the remote seed references of the garden map's plants,
+//  with the plant which uses them (the first one, by plant ID)
+func (gardenMap GardenMapModel) RemoteSeeds() map[string]PlantModel {
+	plantIDs := []string{}
+	for aPlantID := range gardenMap.Plants {
+		plantIDs = append(plantIDs, aPlantID)
+	}
+	sort.Strings(plantIDs)
+
+	remoteSeeds := map[string]PlantModel{}
+	for _, aPlantID := range plantIDs {
+		plantModel := gardenMap.Plants[aPlantID]
+		if _, isFound := remoteSeeds[plantModel.Seed]; isFound {
+			continue
+		}
+		seedRef, err := ParseSeedRef(plantModel.Seed)
+		if err != nil || !seedRef.IsRemote() {
+			continue
+		}
+		remoteSeeds[plantModel.Seed] = plantModel
+	}
+	return remoteSeeds
+}
+
 func (gardenMap GardenMapModel) plantsFilteredByZone(zone string) PlantsMap {
 	filtered := PlantsMap{}
 	for plantID, plantModel := range gardenMap.Plants {
@@ -159,6 +221,12 @@ func CreateGardenMapModelFromYMLFile(pth string) (GardenMapModel, error) {
 	if err := yaml.Unmarshal(fileBytes, &modelToReturn); err != nil {
 		return GardenMapModel{}, err
 	}
+	if err := validateConflictPolicies(modelToReturn.ConflictPolicies); err != nil {
+		return GardenMapModel{}, err
+	}
+	if err := validateSnapshotsConfig(modelToReturn.Snapshots); err != nil {
+		return GardenMapModel{}, err
+	}
 
 	return modelToReturn, nil
 }
@@ -187,10 +255,42 @@ func LoadGardenMap(gardenDirPath string) (GardenMapModel, string, error) {
 	}
 	log.Printf("=> Using Garden directory: %s (abs path: %s)", colorstring.Green(relPath), absPath)
 
-	gardenMapPth := path.Join(absPath, "map.yml")
+	gardenMapPth := path.Join(absPath, GardenMapFileName)
 	gardenMap, err := CreateGardenMapModelFromYMLFile(gardenMapPth)
 	if err != nil {
 		return GardenMapModel{}, "", fmt.Errorf("Failed to load Garden Map (path:%s) with error: %s", gardenMapPth, err)
 	}
 	return gardenMap, absPath, nil
 }
+
+// InitGardenDir ...
+//  creates a new garden dir, with an empty garden map (in strict mode),
+//  an empty seeds directory and a .gitignore for the local directories
+func InitGardenDir(gardenDirPath string) (string, error) {
+	absPath, err := pathutil.AbsPath(gardenDirPath)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get Absolute path of Garden Dir (path:%s), error: %s", gardenDirPath, err)
+	}
+	gardenMapPth := path.Join(absPath, GardenMapFileName)
+	if isExist, err := pathutil.IsPathExists(gardenMapPth); err != nil {
+		return "", err
+	} else if isExist {
+		return "", fmt.Errorf("Garden Map already exists at path: %s", gardenMapPth)
+	}
+
+	if err := os.MkdirAll(path.Join(absPath, "seeds"), 0755); err != nil {
+		return "", fmt.Errorf("Failed to create seeds directory, error: %s", err)
+	}
+	if err := fileutil.WriteStringToFile(gardenMapPth, newGardenMapContent); err != nil {
+		return "", fmt.Errorf("Failed to write Garden Map (path:%s), error: %s", gardenMapPth, err)
+	}
+	gitignorePth := path.Join(absPath, ".gitignore")
+	if isExist, err := pathutil.IsPathExists(gitignorePth); err != nil {
+		return "", err
+	} else if !isExist {
+		if err := fileutil.WriteStringToFile(gitignorePth, newGardenGitignoreContent); err != nil {
+			return "", fmt.Errorf("Failed to write .gitignore (path:%s), error: %s", gitignorePth, err)
+		}
+	}
+	return absPath, nil
+}
@@ -2,8 +2,12 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -65,3 +69,37 @@ func Test_PlantModel_ExpandedPath(t *testing.T) {
 	}
 	require.Equal(t, "abc/PLANT1/a/PLANT1", plant.ExpandedPath("PLANT1"))
 }
+
+func Test_InitGardenDir(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	gardenDirPth := filepath.Join(tmpDir, ".garden")
+
+	gardenDirAbsPth, err := InitGardenDir(gardenDirPth)
+	require.NoError(t, err)
+	require.Equal(t, gardenDirPth, gardenDirAbsPth)
+	isExist, err := pathutil.IsDirExists(filepath.Join(gardenDirPth, "seeds"))
+	require.NoError(t, err)
+	require.True(t, isExist)
+	gitignoreContent, err := fileutil.ReadStringFromFile(filepath.Join(gardenDirPth, ".gitignore"))
+	require.NoError(t, err)
+	require.Equal(t, ".cache/\n.state/\n", gitignoreContent)
+
+	t.Log("New maps are strict")
+	gardenMap, _, err := LoadGardenMap(gardenDirPth)
+	require.NoError(t, err)
+	require.True(t, gardenMap.IsStrictVars())
+	require.Equal(t, 0, len(gardenMap.Plants))
+
+	t.Log("Already initialized")
+	_, err = InitGardenDir(gardenDirPth)
+	require.Error(t, err)
+
+	t.Log("Existing maps without strict_vars are not strict")
+	gardenMap, _, err = loadTestGardenMap()
+	require.NoError(t, err)
+	require.False(t, gardenMap.IsStrictVars())
+}
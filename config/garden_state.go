@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// GardenStateDirName : the directory of the garden's local state,
+	//  in the garden dir; it should not be version controlled
+	GardenStateDirName = ".state"
+	// GardenStateFileName : name of the state file, in the state dir
+	GardenStateFileName = "state.yml"
+	// MergeBasesDirName : the last rendered version of the plants' text files,
+	//  the base of the three-way merges, in the state dir
+	MergeBasesDirName = "bases"
+)
+
+// PlantStateModel ...
+//  what garden knows about a plant from its previous grows
+type PlantStateModel struct {
+	// GrownAt : the start time of the plant's last grow
+	GrownAt time.Time `json:"grown_at" yaml:"grown_at"`
+	// Seed : the seed reference the plant was grown from (the plant's `seed` property)
+	Seed string `json:"seed" yaml:"seed"`
+	// SeedRevision : the resolved revision of a remote seed, empty for local seeds
+	SeedRevision string `json:"seed_revision,omitempty" yaml:"seed_revision,omitempty"`
+	// SeedContentHash : hash of the seed directory's content
+	SeedContentHash string `json:"seed_content_hash" yaml:"seed_content_hash"`
+	// VarsHash : SHA256 hash of the plant's resolved vars
+	VarsHash string `json:"vars_hash" yaml:"vars_hash"`
+	// GardenHash : hash of the garden's partials and plants (IDs, paths, vars and zones)
+	GardenHash string `json:"garden_hash" yaml:"garden_hash"`
+	// PlantPath : the absolute path the plant was grown into
+	PlantPath string `json:"plant_path" yaml:"plant_path"`
+	// GardenVersion : the version of garden which grew the plant
+	GardenVersion string `json:"garden_version" yaml:"garden_version"`
+	// Files : the SHA256 hash of the content garden wrote into the plant's files,
+	//  by slash separated, plant root relative path
+	Files map[string]string `json:"files" yaml:"files"`
+}
+
+// IsGrown ...
+//  whether the plant was grown (and recorded) before
+func (plantState PlantStateModel) IsGrown() bool {
+	return !plantState.GrownAt.IsZero()
+}
+
+// GardenStateModel ...
+//  the garden's local state, by plant ID
+type GardenStateModel struct {
+	Plants map[string]PlantStateModel `json:"plants" yaml:"plants"`
+}
+
+// NewGardenState ...
+func NewGardenState() GardenStateModel {
+	return GardenStateModel{Plants: map[string]PlantStateModel{}}
+}
+
+// NewPlantState ...
+func NewPlantState() PlantStateModel {
+	return PlantStateModel{Files: map[string]string{}}
+}
+
+// PlantState ...
+//  a copy of the plant's state (changing it doesn't change the garden
+//  state), an empty state if the plant was not yet grown
+func (gardenState GardenStateModel) PlantState(plantID string) PlantStateModel {
+	plantState, isFound := gardenState.Plants[plantID]
+	if !isFound {
+		return NewPlantState()
+	}
+	files := map[string]string{}
+	for aPth, aHash := range plantState.Files {
+		files[aPth] = aHash
+	}
+	plantState.Files = files
+	return plantState
+}
+
+// PlantIDs ...
+//  the IDs of the recorded plants, sorted
+func (gardenState GardenStateModel) PlantIDs() []string {
+	plantIDs := []string{}
+	for plantID := range gardenState.Plants {
+		plantIDs = append(plantIDs, plantID)
+	}
+	sort.Strings(plantIDs)
+	return plantIDs
+}
+
+// GardenStateFilePath ...
+func GardenStateFilePath(gardenDirPth string) string {
+	return filepath.Join(gardenDirPth, GardenStateDirName, GardenStateFileName)
+}
+
+// PlantMergeBasesDirPath ...
+//  the dir of the plant's merge bases, with the same layout as the plant
+func PlantMergeBasesDirPath(gardenDirPth, plantID string) string {
+	return filepath.Join(gardenDirPth, GardenStateDirName, MergeBasesDirName, plantID)
+}
+
+// LoadGardenState ...
+//  loads the garden dir's state file (the state of a garden
+//  can be read with this, e.g. by other tools).
+//  An empty state is returned if the garden dir doesn't have one.
+func LoadGardenState(gardenDirPth string) (GardenStateModel, error) {
+	statePth := GardenStateFilePath(gardenDirPth)
+	isExist, err := pathutil.IsPathExists(statePth)
+	if err != nil {
+		return GardenStateModel{}, err
+	}
+	if !isExist {
+		return NewGardenState(), nil
+	}
+
+	fileBytes, err := fileutil.ReadBytesFromFile(statePth)
+	if err != nil {
+		return GardenStateModel{}, err
+	}
+
+	var gardenState GardenStateModel
+	if err := yaml.Unmarshal(fileBytes, &gardenState); err != nil {
+		return GardenStateModel{}, fmt.Errorf("Failed to parse garden state (path:%s), error: %s", statePth, err)
+	}
+	if gardenState.Plants == nil {
+		gardenState.Plants = map[string]PlantStateModel{}
+	}
+	return gardenState, nil
+}
+
+// SaveGardenState ...
+//  the state file is replaced atomically, a crash during the save
+//  can't leave a partially written state file behind
+func SaveGardenState(gardenDirPth string, gardenState GardenStateModel) error {
+	fileBytes, err := yaml.Marshal(gardenState)
+	if err != nil {
+		return err
+	}
+	statePth := GardenStateFilePath(gardenDirPth)
+	if err := os.MkdirAll(filepath.Dir(statePth), 0755); err != nil {
+		return err
+	}
+	if err := writeBytesToFileAtomically(statePth, fileBytes); err != nil {
+		return fmt.Errorf("Failed to write garden state (path:%s), error: %s", statePth, err)
+	}
+	return nil
+}
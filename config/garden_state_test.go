@@ -0,0 +1,65 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadGardenState(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("No state file")
+	gardenState, err := LoadGardenState(tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, NewGardenState(), gardenState)
+	require.Equal(t, NewPlantState(), gardenState.PlantState("not-grown"))
+
+	require.False(t, gardenState.PlantState("not-grown").IsGrown())
+
+	t.Log("Save and load")
+	grownAt := time.Date(2016, 3, 4, 10, 20, 30, 0, time.UTC)
+	gardenState.Plants["app-1"] = PlantStateModel{
+		GrownAt:         grownAt,
+		Seed:            "git+https://github.com/example/seeds.git@v1.0.0",
+		SeedRevision:    "0123abc",
+		SeedContentHash: "def",
+		VarsHash:        "fed",
+		GardenVersion:   "1.0.0",
+		Files:           map[string]string{"README.md": "abc"},
+	}
+	gardenState.Plants["api"] = PlantStateModel{Files: map[string]string{}}
+	require.NoError(t, SaveGardenState(tmpDir, gardenState))
+	loadedState, err := LoadGardenState(tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"api", "app-1"}, loadedState.PlantIDs())
+	require.True(t, loadedState.PlantState("app-1").GrownAt.Equal(grownAt))
+	require.True(t, loadedState.PlantState("app-1").IsGrown())
+	require.Equal(t, "0123abc", loadedState.PlantState("app-1").SeedRevision)
+	require.Equal(t, "abc", loadedState.PlantState("app-1").Files["README.md"])
+
+	t.Log("The returned plant state is a copy")
+	plantState := loadedState.PlantState("app-1")
+	plantState.Files["README.md"] = "changed"
+	require.Equal(t, "abc", loadedState.PlantState("app-1").Files["README.md"])
+
+	t.Log("The save replaces the state file, without leaving temporary files behind")
+	delete(gardenState.Plants, "api")
+	require.NoError(t, SaveGardenState(tmpDir, gardenState))
+	loadedState, err = LoadGardenState(tmpDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"app-1"}, loadedState.PlantIDs())
+	fileInfos, err := ioutil.ReadDir(filepath.Join(tmpDir, GardenStateDirName))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(fileInfos))
+	require.Equal(t, GardenStateFileName, fileInfos[0].Name())
+}
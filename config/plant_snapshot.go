@@ -0,0 +1,216 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SnapshotsDirName : the snapshots of the plants, in the state dir
+	//  (a plant's snapshots are in a sub dir named after the plant's ID)
+	SnapshotsDirName = "snapshots"
+	// SnapshotFileName : the description of the snapshot, in the snapshot's dir
+	SnapshotFileName = "snapshot.yml"
+	// SnapshotFilesDirName : the saved files of the plant, in the snapshot's dir,
+	//  with the same layout as the plant
+	SnapshotFilesDirName = "files"
+	// SnapshotMergeBasesDirName : the saved merge bases of the plant, in the snapshot's dir
+	SnapshotMergeBasesDirName = "bases"
+	// SnapshotIDTimeFormat : the format of the snapshot IDs (the time the snapshot was taken, in UTC)
+	SnapshotIDTimeFormat = "20060102T150405Z"
+
+	// DefaultSnapshotsKeep : the number of snapshots kept for every plant,
+	//  if the garden map doesn't set it
+	DefaultSnapshotsKeep = 10
+)
+
+// SnapshotsConfigModel ...
+//  the retention of the plants' snapshots, the snapshots which are
+//  not kept are removed after a new snapshot is taken
+type SnapshotsConfigModel struct {
+	// Keep : the number of the latest snapshots kept for every plant
+	//  (default: DefaultSnapshotsKeep)
+	Keep int `json:"keep,omitempty" yaml:"keep,omitempty"`
+	// MaxAgeDays : the snapshots older than this are removed,
+	//  the latest snapshot of a plant is always kept (default: no limit)
+	MaxAgeDays int `json:"max_age_days,omitempty" yaml:"max_age_days,omitempty"`
+}
+
+// SnapshotsConfig ...
+//  the garden map's snapshot retention, with the defaults
+func (gardenMap GardenMapModel) SnapshotsConfig() SnapshotsConfigModel {
+	snapshotsConfig := SnapshotsConfigModel{}
+	if gardenMap.Snapshots != nil {
+		snapshotsConfig = *gardenMap.Snapshots
+	}
+	if snapshotsConfig.Keep == 0 {
+		snapshotsConfig.Keep = DefaultSnapshotsKeep
+	}
+	return snapshotsConfig
+}
+
+// validateSnapshotsConfig ...
+func validateSnapshotsConfig(snapshotsConfig *SnapshotsConfigModel) error {
+	if snapshotsConfig == nil {
+		return nil
+	}
+	if snapshotsConfig.Keep < 0 {
+		return fmt.Errorf("Invalid snapshots keep (%d), at least 1 snapshot has to be kept", snapshotsConfig.Keep)
+	}
+	if snapshotsConfig.MaxAgeDays < 0 {
+		return fmt.Errorf("Invalid snapshots max_age_days (%d)", snapshotsConfig.MaxAgeDays)
+	}
+	return nil
+}
+
+// PlantSnapshotModel ...
+//  the garden managed files of a plant (the files recorded in
+//  the garden state), saved before a grow changed them
+type PlantSnapshotModel struct {
+	// ID : the time the snapshot was taken (SnapshotIDTimeFormat),
+	//  the name of the snapshot's dir
+	ID      string    `json:"-" yaml:"-"`
+	PlantID string    `json:"plant_id" yaml:"plant_id"`
+	TakenAt time.Time `json:"taken_at" yaml:"taken_at"`
+	// PlantPath : the absolute path of the plant
+	PlantPath string `json:"plant_path" yaml:"plant_path"`
+	// Files : the SHA256 hash of the saved files, by slash separated,
+	//  plant root relative path
+	Files map[string]string `json:"files" yaml:"files"`
+	// PlantState : the plant's state when the snapshot was taken,
+	//  it's restored with the files
+	PlantState PlantStateModel `json:"plant_state" yaml:"plant_state"`
+}
+
+// PlantSnapshotsDirPath ...
+func PlantSnapshotsDirPath(gardenDirPth, plantID string) string {
+	return filepath.Join(gardenDirPth, GardenStateDirName, SnapshotsDirName, plantID)
+}
+
+// PlantSnapshotDirPath ...
+func PlantSnapshotDirPath(gardenDirPth, plantID, snapshotID string) string {
+	return filepath.Join(PlantSnapshotsDirPath(gardenDirPth, plantID), snapshotID)
+}
+
+// NewPlantSnapshotID ...
+//  the ID of a snapshot taken at the time; if the plant already has
+//  a snapshot with the same ID a counter is appended to it
+func NewPlantSnapshotID(gardenDirPth, plantID string, takenAt time.Time) (string, error) {
+	baseID := takenAt.UTC().Format(SnapshotIDTimeFormat)
+	snapshotID := baseID
+	for idx := 2; ; idx++ {
+		isExist, err := pathutil.IsPathExists(PlantSnapshotDirPath(gardenDirPth, plantID, snapshotID))
+		if err != nil {
+			return "", err
+		}
+		if !isExist {
+			return snapshotID, nil
+		}
+		snapshotID = fmt.Sprintf("%s-%d", baseID, idx)
+	}
+}
+
+// SavePlantSnapshot ...
+//  writes the snapshot's description into the snapshot's dir
+//  (the snapshot's files have to be saved into its files dir)
+func SavePlantSnapshot(gardenDirPth string, snapshot PlantSnapshotModel) error {
+	if snapshot.ID == "" {
+		return errors.New("Snapshot ID is empty")
+	}
+	fileBytes, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	snapshotDirPth := PlantSnapshotDirPath(gardenDirPth, snapshot.PlantID, snapshot.ID)
+	if err := os.MkdirAll(snapshotDirPth, 0755); err != nil {
+		return err
+	}
+	return writeBytesToFileAtomically(filepath.Join(snapshotDirPth, SnapshotFileName), fileBytes)
+}
+
+// LoadPlantSnapshots ...
+//  the plant's snapshots, from the oldest to the latest
+func LoadPlantSnapshots(gardenDirPth, plantID string) ([]PlantSnapshotModel, error) {
+	snapshotsDirPth := PlantSnapshotsDirPath(gardenDirPth, plantID)
+	fileInfos, err := ioutil.ReadDir(snapshotsDirPth)
+	if os.IsNotExist(err) {
+		return []PlantSnapshotModel{}, nil
+	} else if err != nil {
+		return []PlantSnapshotModel{}, err
+	}
+
+	snapshots := []PlantSnapshotModel{}
+	for _, aFileInfo := range fileInfos {
+		snapshotFilePth := filepath.Join(snapshotsDirPth, aFileInfo.Name(), SnapshotFileName)
+		if isExist, err := pathutil.IsPathExists(snapshotFilePth); err != nil {
+			return []PlantSnapshotModel{}, err
+		} else if !aFileInfo.IsDir() || !isExist {
+			// not a snapshot, or a snapshot which was not finished
+			continue
+		}
+		fileBytes, err := fileutil.ReadBytesFromFile(snapshotFilePth)
+		if err != nil {
+			return []PlantSnapshotModel{}, err
+		}
+		var snapshot PlantSnapshotModel
+		if err := yaml.Unmarshal(fileBytes, &snapshot); err != nil {
+			return []PlantSnapshotModel{}, fmt.Errorf("Failed to parse snapshot (path:%s), error: %s", snapshotFilePth, err)
+		}
+		snapshot.ID = aFileInfo.Name()
+		if snapshot.Files == nil {
+			snapshot.Files = map[string]string{}
+		}
+		if snapshot.PlantState.Files == nil {
+			snapshot.PlantState.Files = map[string]string{}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Sort(snapshotsByTime(snapshots))
+	return snapshots, nil
+}
+
+// snapshotsByTime ...
+//  sorts the snapshots from the oldest to the latest
+type snapshotsByTime []PlantSnapshotModel
+
+func (snapshots snapshotsByTime) Len() int {
+	return len(snapshots)
+}
+
+func (snapshots snapshotsByTime) Swap(i, j int) {
+	snapshots[i], snapshots[j] = snapshots[j], snapshots[i]
+}
+
+func (snapshots snapshotsByTime) Less(i, j int) bool {
+	if !snapshots[i].TakenAt.Equal(snapshots[j].TakenAt) {
+		return snapshots[i].TakenAt.Before(snapshots[j].TakenAt)
+	}
+	return snapshots[i].ID < snapshots[j].ID
+}
+
+// ExpiredSnapshots ...
+//  the snapshots (sorted from the oldest to the latest) which are not
+//  kept by the retention config: the ones which are not among the latest
+//  Keep snapshots, or are older than MaxAgeDays (except the latest one)
+func ExpiredSnapshots(snapshots []PlantSnapshotModel, snapshotsConfig SnapshotsConfigModel, now time.Time) []PlantSnapshotModel {
+	expired := []PlantSnapshotModel{}
+	for idx, aSnapshot := range snapshots {
+		countAfter := len(snapshots) - idx - 1
+		isTooMany := snapshotsConfig.Keep > 0 && countAfter >= snapshotsConfig.Keep
+		isTooOld := snapshotsConfig.MaxAgeDays > 0 && countAfter > 0 &&
+			now.Sub(aSnapshot.TakenAt) > time.Duration(snapshotsConfig.MaxAgeDays)*24*time.Hour
+		if isTooMany || isTooOld {
+			expired = append(expired, aSnapshot)
+		}
+	}
+	return expired
+}
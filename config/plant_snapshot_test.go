@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadPlantSnapshots(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("No snapshots")
+	snapshots, err := LoadPlantSnapshots(tmpDir, "app-1")
+	require.NoError(t, err)
+	require.Equal(t, []PlantSnapshotModel{}, snapshots)
+
+	t.Log("Save and load - sorted by time, a new snapshot at the same time gets a new ID")
+	takenAt := time.Date(2016, 3, 4, 10, 20, 30, 0, time.UTC)
+	for _, aTakenAt := range []time.Time{takenAt, takenAt.Add(-time.Hour), takenAt} {
+		snapshotID, err := NewPlantSnapshotID(tmpDir, "app-1", aTakenAt)
+		require.NoError(t, err)
+		require.NoError(t, SavePlantSnapshot(tmpDir, PlantSnapshotModel{
+			ID:         snapshotID,
+			PlantID:    "app-1",
+			TakenAt:    aTakenAt,
+			Files:      map[string]string{"README.md": "abc"},
+			PlantState: PlantStateModel{GrownAt: aTakenAt.Add(-time.Minute), Files: map[string]string{"README.md": "abc"}},
+		}))
+	}
+	// not finished snapshot
+	require.NoError(t, os.MkdirAll(PlantSnapshotDirPath(tmpDir, "app-1", "20160304T000000Z"), 0755))
+
+	snapshots, err = LoadPlantSnapshots(tmpDir, "app-1")
+	require.NoError(t, err)
+	snapshotIDs := []string{}
+	for _, aSnapshot := range snapshots {
+		snapshotIDs = append(snapshotIDs, aSnapshot.ID)
+	}
+	require.Equal(t, []string{"20160304T092030Z", "20160304T102030Z", "20160304T102030Z-2"}, snapshotIDs)
+	require.True(t, snapshots[0].TakenAt.Equal(takenAt.Add(-time.Hour)))
+	require.Equal(t, "abc", snapshots[0].Files["README.md"])
+	require.Equal(t, "abc", snapshots[0].PlantState.Files["README.md"])
+}
+
+func Test_ExpiredSnapshots(t *testing.T) {
+	now := time.Date(2016, 3, 10, 0, 0, 0, 0, time.UTC)
+	snapshots := []PlantSnapshotModel{
+		PlantSnapshotModel{ID: "a", TakenAt: now.AddDate(0, 0, -40)},
+		PlantSnapshotModel{ID: "b", TakenAt: now.AddDate(0, 0, -20)},
+		PlantSnapshotModel{ID: "c", TakenAt: now.AddDate(0, 0, -10)},
+		PlantSnapshotModel{ID: "d", TakenAt: now.AddDate(0, 0, -1)},
+	}
+	expiredIDs := func(snapshotsConfig SnapshotsConfigModel, snapshots []PlantSnapshotModel) []string {
+		ids := []string{}
+		for _, aSnapshot := range ExpiredSnapshots(snapshots, snapshotsConfig, now) {
+			ids = append(ids, aSnapshot.ID)
+		}
+		return ids
+	}
+
+	t.Log("Keep the latest ones")
+	require.Equal(t, []string{}, expiredIDs(SnapshotsConfigModel{Keep: 10}, snapshots))
+	require.Equal(t, []string{"a", "b"}, expiredIDs(SnapshotsConfigModel{Keep: 2}, snapshots))
+
+	t.Log("Max age")
+	require.Equal(t, []string{"a", "b"}, expiredIDs(SnapshotsConfigModel{Keep: 10, MaxAgeDays: 15}, snapshots))
+	require.Equal(t, []string{"a", "b", "c"}, expiredIDs(SnapshotsConfigModel{Keep: 3, MaxAgeDays: 5}, snapshots))
+
+	t.Log("The latest snapshot is kept, even if it's too old")
+	require.Equal(t, []string{}, expiredIDs(SnapshotsConfigModel{Keep: 10, MaxAgeDays: 5}, snapshots[:1]))
+}
+
+func Test_SnapshotsConfig(t *testing.T) {
+	require.Equal(t, SnapshotsConfigModel{Keep: DefaultSnapshotsKeep}, GardenMapModel{}.SnapshotsConfig())
+	require.Equal(t, SnapshotsConfigModel{Keep: DefaultSnapshotsKeep, MaxAgeDays: 30}, GardenMapModel{Snapshots: &SnapshotsConfigModel{MaxAgeDays: 30}}.SnapshotsConfig())
+	require.Equal(t, SnapshotsConfigModel{Keep: 3}, GardenMapModel{Snapshots: &SnapshotsConfigModel{Keep: 3}}.SnapshotsConfig())
+
+	require.NoError(t, validateSnapshotsConfig(nil))
+	require.NoError(t, validateSnapshotsConfig(&SnapshotsConfigModel{Keep: 3, MaxAgeDays: 30}))
+	require.Error(t, validateSnapshotsConfig(&SnapshotsConfigModel{Keep: -1}))
+	require.Error(t, validateSnapshotsConfig(&SnapshotsConfigModel{MaxAgeDays: -1}))
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SeedMetaDirName : the directory inside a seed which holds
+	//  the seed's configuration and other seed related files,
+	//  which should not be copied into the plant
+	SeedMetaDirName = ".seed"
+	// SeedConfigFileName : name of the seed configuration file,
+	//  inside the seed's meta dir
+	SeedConfigFileName = "seed.yml"
+)
+
+const (
+	// FileModeOverwrite : the seed's file replaces the plant's file (default)
+	FileModeOverwrite = "overwrite"
+	// FileModeAppend : the lines of the seed's file which are not yet
+	//  in the plant's file are appended to it
+	FileModeAppend = "append"
+	// FileModePatch : the seed's file is a unified diff,
+	//  which is applied on the plant's file
+	FileModePatch = "patch"
+	// FileModeMerge : the seed's file (JSON or YAML) is deep merged
+	//  into the plant's file
+	FileModeMerge = "merge"
+)
+
+// FileModeModel ...
+type FileModeModel struct {
+	// Pattern : glob pattern, matched against the file's path,
+	//  relative to the seed's root (e.g. ".gitignore" or "config/*.json")
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Mode    string `json:"mode" yaml:"mode"`
+}
+
+// PermissionModel ...
+type PermissionModel struct {
+	// Pattern : glob pattern, matched against the rendered file's
+	//  (or directory's) path, relative to the plant's root (e.g. "secrets/*")
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Mode : octal permission bits, e.g. "0600"
+	Mode string `json:"mode" yaml:"mode"`
+}
+
+// FileMode ...
+func (permission PermissionModel) FileMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(permission.Mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid permission mode (%s), should be an octal number, e.g. 0644", permission.Mode)
+	}
+	if mode > 0777 {
+		return 0, fmt.Errorf("Invalid permission mode (%s), can't be larger than 0777", permission.Mode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// SymlinkModel ...
+type SymlinkModel struct {
+	// Path : path of the symlink, relative to the plant's root
+	Path string `json:"path" yaml:"path"`
+	// Target : the symlink's target, can include template actions
+	//  (e.g. "{{ .GardenDir }}/tools")
+	Target string `json:"target" yaml:"target"`
+}
+
+// SeedConfigModel ...
+type SeedConfigModel struct {
+	// TemplateDelimiters : the left and right delimiters to use for
+	//  the seed's template files, instead of the default {{ and }}
+	TemplateDelimiters []string `json:"template_delimiters" yaml:"template_delimiters"`
+	// FileModes : how the seed's files should be written into the plant,
+	//  the first matching pattern's mode is used
+	FileModes []FileModeModel `json:"file_modes" yaml:"file_modes"`
+	// Permissions : permissions of the rendered files and directories,
+	//  the first matching pattern's mode is used
+	Permissions []PermissionModel `json:"permissions" yaml:"permissions"`
+	// Symlinks : symlinks to create in the plant
+	Symlinks []SymlinkModel `json:"symlinks" yaml:"symlinks"`
+	// Directories : (empty) directories to create in the plant,
+	//  relative to the plant's root
+	Directories []string `json:"directories" yaml:"directories"`
+}
+
+// isRelativeSeedPath ...
+//  a relative path which doesn't point outside of the seed
+func isRelativeSeedPath(pth string) bool {
+	if pth == "" || path.IsAbs(pth) {
+		return false
+	}
+	cleanPth := path.Clean(pth)
+	return cleanPth != "." && cleanPth != ".." && !strings.HasPrefix(cleanPth, "../")
+}
+
+// PermissionForPath ...
+//  returns the mode of the first Permissions item which matches the
+//  (slash separated, plant root relative) path
+func (seedConfig SeedConfigModel) PermissionForPath(relPth string) (os.FileMode, bool) {
+	for _, aPermission := range seedConfig.Permissions {
+		if isMatch, err := path.Match(aPermission.Pattern, relPth); err == nil && isMatch {
+			if mode, err := aPermission.FileMode(); err == nil {
+				return mode, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// IsValidFileMode ...
+func IsValidFileMode(mode string) bool {
+	switch mode {
+	case FileModeOverwrite, FileModeAppend, FileModePatch, FileModeMerge:
+		return true
+	}
+	return false
+}
+
+// FileModeForPath ...
+//  returns the mode of the first FileModes item which matches the
+//  (slash separated, seed root relative) path, or FileModeOverwrite
+//  if none matches
+func (seedConfig SeedConfigModel) FileModeForPath(relPth string) string {
+	for _, aFileMode := range seedConfig.FileModes {
+		if isMatch, err := path.Match(aFileMode.Pattern, relPth); err == nil && isMatch {
+			return aFileMode.Mode
+		}
+	}
+	return FileModeOverwrite
+}
+
+// Validate ...
+func (seedConfig SeedConfigModel) Validate() error {
+	if len(seedConfig.TemplateDelimiters) != 0 {
+		if len(seedConfig.TemplateDelimiters) != 2 {
+			return fmt.Errorf("template_delimiters should have exactly 2 items (left and right delimiter), got: %d", len(seedConfig.TemplateDelimiters))
+		}
+		for _, aDelim := range seedConfig.TemplateDelimiters {
+			if aDelim == "" {
+				return fmt.Errorf("template_delimiters can't include an empty delimiter")
+			}
+		}
+	}
+
+	for _, aFileMode := range seedConfig.FileModes {
+		if _, err := path.Match(aFileMode.Pattern, ""); err != nil {
+			return fmt.Errorf("Invalid file_modes pattern (%s), error: %s", aFileMode.Pattern, err)
+		}
+		if !IsValidFileMode(aFileMode.Mode) {
+			return fmt.Errorf("Invalid file mode (%s) for pattern: %s", aFileMode.Mode, aFileMode.Pattern)
+		}
+	}
+
+	for _, aPermission := range seedConfig.Permissions {
+		if _, err := path.Match(aPermission.Pattern, ""); err != nil {
+			return fmt.Errorf("Invalid permissions pattern (%s), error: %s", aPermission.Pattern, err)
+		}
+		if _, err := aPermission.FileMode(); err != nil {
+			return err
+		}
+	}
+
+	for _, aSymlink := range seedConfig.Symlinks {
+		if !isRelativeSeedPath(aSymlink.Path) {
+			return fmt.Errorf("Invalid symlink path (%s), should be a path relative to the plant's root", aSymlink.Path)
+		}
+		if aSymlink.Target == "" {
+			return fmt.Errorf("No target specified for symlink: %s", aSymlink.Path)
+		}
+	}
+
+	for _, aDirectory := range seedConfig.Directories {
+		if !isRelativeSeedPath(aDirectory) {
+			return fmt.Errorf("Invalid directory (%s), should be a path relative to the plant's root", aDirectory)
+		}
+	}
+
+	return nil
+}
+
+// SeedConfigFilePath ...
+func SeedConfigFilePath(seedDirPth string) string {
+	return filepath.Join(seedDirPth, SeedMetaDirName, SeedConfigFileName)
+}
+
+// LoadSeedConfig ...
+//  loads the seed's configuration from the seed's meta dir.
+//  The configuration file is optional, an empty configuration
+//  is returned if the seed doesn't have one.
+func LoadSeedConfig(seedDirPth string) (SeedConfigModel, error) {
+	configPth := SeedConfigFilePath(seedDirPth)
+	isExist, err := pathutil.IsPathExists(configPth)
+	if err != nil {
+		return SeedConfigModel{}, err
+	}
+	if !isExist {
+		return SeedConfigModel{}, nil
+	}
+
+	fileBytes, err := fileutil.ReadBytesFromFile(configPth)
+	if err != nil {
+		return SeedConfigModel{}, err
+	}
+
+	var seedConfig SeedConfigModel
+	if err := yaml.Unmarshal(fileBytes, &seedConfig); err != nil {
+		return SeedConfigModel{}, fmt.Errorf("Failed to parse seed config (path:%s), error: %s", configPth, err)
+	}
+	if err := seedConfig.Validate(); err != nil {
+		return SeedConfigModel{}, fmt.Errorf("Invalid seed config (path:%s), error: %s", configPth, err)
+	}
+
+	return seedConfig, nil
+}
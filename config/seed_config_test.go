@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadSeedConfig(t *testing.T) {
+	seedDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(seedDir))
+	}()
+
+	t.Log("No seed config - empty config")
+	seedConfig, err := LoadSeedConfig(seedDir)
+	require.NoError(t, err)
+	require.Equal(t, SeedConfigModel{}, seedConfig)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(seedDir, SeedMetaDirName), 0777))
+
+	t.Log("Template delimiters")
+	require.NoError(t, fileutil.WriteStringToFile(SeedConfigFilePath(seedDir), `template_delimiters: ["[[", "]]"]`))
+	seedConfig, err = LoadSeedConfig(seedDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"[[", "]]"}, seedConfig.TemplateDelimiters)
+
+	t.Log("Invalid template delimiters")
+	require.NoError(t, fileutil.WriteStringToFile(SeedConfigFilePath(seedDir), `template_delimiters: ["[["]`))
+	_, err = LoadSeedConfig(seedDir)
+	require.Error(t, err)
+
+	require.NoError(t, fileutil.WriteStringToFile(SeedConfigFilePath(seedDir), `template_delimiters: ["[[", ""]`))
+	_, err = LoadSeedConfig(seedDir)
+	require.Error(t, err)
+
+	t.Log("File modes")
+	require.NoError(t, fileutil.WriteStringToFile(SeedConfigFilePath(seedDir), `file_modes:
+- pattern: .gitignore
+  mode: append
+- pattern: "config/*.json"
+  mode: merge
+`))
+	seedConfig, err = LoadSeedConfig(seedDir)
+	require.NoError(t, err)
+	require.Equal(t, FileModeAppend, seedConfig.FileModeForPath(".gitignore"))
+	require.Equal(t, FileModeMerge, seedConfig.FileModeForPath("config/app.json"))
+	require.Equal(t, FileModeOverwrite, seedConfig.FileModeForPath("config/sub/app.json"))
+
+	t.Log("Invalid file mode")
+	require.NoError(t, fileutil.WriteStringToFile(SeedConfigFilePath(seedDir), `file_modes:
+- pattern: .gitignore
+  mode: prepend
+`))
+	_, err = LoadSeedConfig(seedDir)
+	require.Error(t, err)
+	t.Log("Permissions, symlinks and directories")
+	require.NoError(t, fileutil.WriteStringToFile(SeedConfigFilePath(seedDir), `permissions:
+- pattern: "secrets/*"
+  mode: 0600
+- pattern: "*.sh"
+  mode: "0755"
+symlinks:
+- path: tools
+  target: "{{ .GardenDir }}/tools"
+directories:
+- logs
+`))
+	seedConfig, err = LoadSeedConfig(seedDir)
+	require.NoError(t, err)
+	mode, isFound := seedConfig.PermissionForPath("secrets/app.env")
+	require.True(t, isFound)
+	require.Equal(t, os.FileMode(0600), mode)
+	mode, isFound = seedConfig.PermissionForPath("run.sh")
+	require.True(t, isFound)
+	require.Equal(t, os.FileMode(0755), mode)
+	_, isFound = seedConfig.PermissionForPath("README.md")
+	require.False(t, isFound)
+	require.Equal(t, []SymlinkModel{{Path: "tools", Target: "{{ .GardenDir }}/tools"}}, seedConfig.Symlinks)
+	require.Equal(t, []string{"logs"}, seedConfig.Directories)
+
+	t.Log("Invalid permissions, symlinks and directories")
+	for _, aConfigContent := range []string{
+		"permissions:\n- pattern: a\n  mode: rw\n",
+		"permissions:\n- pattern: a\n  mode: 1777\n",
+		"permissions:\n- pattern: \"[\"\n  mode: 0644\n",
+		"symlinks:\n- path: ../outside\n  target: x\n",
+		"symlinks:\n- path: /abs\n  target: x\n",
+		"symlinks:\n- path: tools\n",
+		"directories:\n- ../logs\n",
+	} {
+		require.NoError(t, fileutil.WriteStringToFile(SeedConfigFilePath(seedDir), aConfigContent))
+		_, err = LoadSeedConfig(seedDir)
+		require.Error(t, err, aConfigContent)
+	}
+}
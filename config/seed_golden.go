@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SeedTestsDirName : the directory of the seed's golden test cases,
+	//  inside the seed's meta dir; every sub directory is a test case
+	SeedTestsDirName = "tests"
+	// SeedTestVarsFileName : the test case's plant definition (optional)
+	SeedTestVarsFileName = "vars.yml"
+	// SeedTestGoldenDirName : the expected output of the test case
+	SeedTestGoldenDirName = "golden"
+	// SeedTestPlantDirName : the plant's content before the grow (optional),
+	//  to test the append, patch and merge file modes
+	SeedTestPlantDirName = "plant"
+)
+
+// SeedTestCaseModel ...
+//  the plant a seed's golden test case is grown as
+type SeedTestCaseModel struct {
+	// PlantID : the test case's name if not specified
+	PlantID string `json:"plant_id" yaml:"plant_id"`
+	// Path : /plants/$_GARDEN_PLANT_ID if not specified
+	Path  string       `json:"path" yaml:"path"`
+	Vars  PlantVarsMap `json:"vars" yaml:"vars"`
+	Zones []string     `json:"zones" yaml:"zones"`
+}
+
+// SeedTestsDirPath ...
+func SeedTestsDirPath(seedDirPth string) string {
+	return filepath.Join(seedDirPth, SeedMetaDirName, SeedTestsDirName)
+}
+
+// LoadSeedTestCase ...
+//  loads the test case's vars file, from the test case's directory
+func LoadSeedTestCase(testCaseDirPth string) (SeedTestCaseModel, error) {
+	testCase := SeedTestCaseModel{}
+	varsPth := filepath.Join(testCaseDirPth, SeedTestVarsFileName)
+	isExist, err := pathutil.IsPathExists(varsPth)
+	if err != nil {
+		return SeedTestCaseModel{}, err
+	}
+	if isExist {
+		fileBytes, err := fileutil.ReadBytesFromFile(varsPth)
+		if err != nil {
+			return SeedTestCaseModel{}, err
+		}
+		if err := yaml.Unmarshal(fileBytes, &testCase); err != nil {
+			return SeedTestCaseModel{}, fmt.Errorf("Failed to parse seed test case (path:%s), error: %s", varsPth, err)
+		}
+	}
+
+	if testCase.PlantID == "" {
+		testCase.PlantID = filepath.Base(testCaseDirPth)
+	}
+	if testCase.Path == "" {
+		testCase.Path = "/plants/$_GARDEN_PLANT_ID"
+	}
+	return testCase, nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/fileutil"
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LoadSeedTestCase(t *testing.T) {
+	tmpDir, err := pathutil.NormalizedOSTempDirPath("")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	t.Log("No vars file - defaults")
+	testCaseDirPth := filepath.Join(tmpDir, "basic")
+	require.NoError(t, os.MkdirAll(testCaseDirPth, 0777))
+	testCase, err := LoadSeedTestCase(testCaseDirPth)
+	require.NoError(t, err)
+	require.Equal(t, SeedTestCaseModel{PlantID: "basic", Path: "/plants/$_GARDEN_PLANT_ID"}, testCase)
+
+	t.Log("Vars file")
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(testCaseDirPth, SeedTestVarsFileName),
+		"plant_id: apple-1\nzones:\n- fruits\nvars:\n  MyVar: value\n"))
+	testCase, err = LoadSeedTestCase(testCaseDirPth)
+	require.NoError(t, err)
+	require.Equal(t, SeedTestCaseModel{
+		PlantID: "apple-1",
+		Path:    "/plants/$_GARDEN_PLANT_ID",
+		Vars:    PlantVarsMap{"MyVar": "value"},
+		Zones:   []string{"fruits"},
+	}, testCase)
+
+	t.Log("Invalid vars file")
+	require.NoError(t, fileutil.WriteStringToFile(filepath.Join(testCaseDirPth, SeedTestVarsFileName), "vars: [\n"))
+	_, err = LoadSeedTestCase(testCaseDirPth)
+	require.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// SeedSourceLocal : a seed in the garden dir's seeds directory
+	SeedSourceLocal = "local"
+	// SeedSourceGit : a seed in a git repository
+	SeedSourceGit = "git"
+	// SeedSourceArchive : a seed in a .tar.gz or .zip archive,
+	//  either a local file or a file downloaded through HTTP(S)
+	SeedSourceArchive = "archive"
+
+	gitSeedPrefix = "git+"
+)
+
+var archiveExtensions = []string{".tar.gz", ".tgz", ".zip"}
+
+// SeedRefModel ...
+//  a parsed seed reference (the `seed` property of a plant)
+type SeedRefModel struct {
+	Source string
+	// Name : name of a local seed (directory name in the garden's seeds dir)
+	Name string
+	// URL : URL of a remote seed (for local archives it's the archive's path)
+	URL string
+	// SubDir : the seed's directory inside the remote seed (optional)
+	SubDir string
+	// Ref : the git branch, tag or commit (optional, the remote's default branch if empty)
+	Ref string
+}
+
+// IsArchivePath ...
+//  whether the path (or URL) points to a supported archive (.tar.gz, .tgz or .zip)
+func IsArchivePath(pth string) bool {
+	for _, anExt := range archiveExtensions {
+		if strings.HasSuffix(strings.ToLower(pth), anExt) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRemote ...
+func (seedRef SeedRefModel) IsRemote() bool {
+	return seedRef.Source != SeedSourceLocal
+}
+
+// splitSubDir ...
+//  splits `url//subdir` into url and subdir;
+//  the `//` of the URL's scheme (e.g. file:///) is not a separator
+func splitSubDir(url string) (string, string) {
+	searchFrom := 0
+	if idx := strings.Index(url, "://"); idx >= 0 {
+		searchFrom = idx + len("://")
+	}
+	idx := strings.Index(url[searchFrom:], "//")
+	if idx < 0 {
+		return url, ""
+	}
+	idx += searchFrom
+	return url[:idx], strings.Trim(url[idx+len("//"):], "/")
+}
+
+// gitURLPathStart ...
+//  the index where the path of the git URL starts: the user info
+//  (e.g. ssh://git@host/... or git@host:...) is before it, so the ref's @
+//  separator is searched only after it
+func gitURLPathStart(url string) int {
+	if idx := strings.Index(url, "://"); idx >= 0 {
+		hostStart := idx + len("://")
+		if slashIdx := strings.Index(url[hostStart:], "/"); slashIdx >= 0 {
+			return hostStart + slashIdx
+		}
+		return len(url)
+	}
+	// scp-like syntax: [user@]host:path
+	colonIdx := strings.Index(url, ":")
+	if colonIdx >= 0 && !strings.Contains(url[:colonIdx], "/") {
+		return colonIdx + 1
+	}
+	return 0
+}
+
+// validateSubDir ...
+//  the sub dir has to be a relative path inside the remote seed
+func validateSubDir(seed, subDir string) error {
+	if filepath.IsAbs(subDir) || strings.HasPrefix(subDir, `\`) || filepath.VolumeName(subDir) != "" {
+		return fmt.Errorf("Invalid seed reference, the sub directory can't be an absolute path: %s", seed)
+	}
+	for _, aComponent := range strings.FieldsFunc(subDir, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if aComponent == ".." {
+			return fmt.Errorf("Invalid seed reference, the sub directory can't contain '..': %s", seed)
+		}
+	}
+	return nil
+}
+
+// ParseSeedRef ...
+//  parses a plant's seed reference:
+//  * `name` : local seed, from the garden dir's seeds directory
+//  * `git+URL[//subdir][@ref]` : seed from a git repository,
+//    e.g. `git+file:///path/to/repo//seeds/apples@v1.0.0`
+//    or `git+https://github.com/org/seeds.git//apples@feature/x`
+//    (the ref is after the last @ of the URL's path, so it can include /)
+//  * `PATH-OR-URL[//subdir]` where PATH-OR-URL ends with .tar.gz, .tgz or .zip :
+//    seed from an archive, e.g. `https://example.com/seeds-1.0.tar.gz//apples`
+func ParseSeedRef(seed string) (SeedRefModel, error) {
+	if seed == "" {
+		return SeedRefModel{}, fmt.Errorf("Empty seed reference")
+	}
+
+	if !strings.HasPrefix(seed, gitSeedPrefix) {
+		url, subDir := splitSubDir(seed)
+		if IsArchivePath(url) {
+			if err := validateSubDir(seed, subDir); err != nil {
+				return SeedRefModel{}, err
+			}
+			return SeedRefModel{
+				Source: SeedSourceArchive,
+				URL:    url,
+				SubDir: subDir,
+			}, nil
+		}
+		return SeedRefModel{Source: SeedSourceLocal, Name: seed}, nil
+	}
+
+	url := strings.TrimPrefix(seed, gitSeedPrefix)
+	ref := ""
+	pathStart := gitURLPathStart(url)
+	if atIdx := strings.LastIndex(url[pathStart:], "@"); atIdx >= 0 {
+		atIdx += pathStart
+		ref = url[atIdx+1:]
+		url = url[:atIdx]
+		if ref == "" {
+			return SeedRefModel{}, fmt.Errorf("Invalid git seed reference, no ref specified after the @: %s", seed)
+		}
+	}
+	url, subDir := splitSubDir(url)
+	if url == "" {
+		return SeedRefModel{}, fmt.Errorf("Invalid git seed reference, no URL specified: %s", seed)
+	}
+	// these are passed to git as arguments, they can't be read as options
+	if strings.HasPrefix(url, "-") {
+		return SeedRefModel{}, fmt.Errorf("Invalid git seed reference, the URL can't start with '-': %s", seed)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return SeedRefModel{}, fmt.Errorf("Invalid git seed reference, the ref can't start with '-': %s", seed)
+	}
+	if err := validateSubDir(seed, subDir); err != nil {
+		return SeedRefModel{}, err
+	}
+
+	return SeedRefModel{
+		Source: SeedSourceGit,
+		URL:    url,
+		SubDir: subDir,
+		Ref:    ref,
+	}, nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseSeedRef(t *testing.T) {
+	t.Log("Local seed")
+	seedRef, err := ParseSeedRef("apples")
+	require.NoError(t, err)
+	require.Equal(t, SeedRefModel{Source: SeedSourceLocal, Name: "apples"}, seedRef)
+	require.False(t, seedRef.IsRemote())
+
+	t.Log("Git - file URL with subdir and ref")
+	seedRef, err = ParseSeedRef("git+file:///path/to/repo//seeds/apples@v1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, SeedRefModel{
+		Source: SeedSourceGit,
+		URL:    "file:///path/to/repo",
+		SubDir: "seeds/apples",
+		Ref:    "v1.0.0",
+	}, seedRef)
+	require.True(t, seedRef.IsRemote())
+
+	t.Log("Git - no subdir, no ref")
+	seedRef, err = ParseSeedRef("git+https://github.com/org/seeds.git")
+	require.NoError(t, err)
+	require.Equal(t, SeedRefModel{Source: SeedSourceGit, URL: "https://github.com/org/seeds.git"}, seedRef)
+
+	t.Log("Git - ssh URL with user")
+	seedRef, err = ParseSeedRef("git+ssh://git@github.com/org/seeds.git//apples")
+	require.NoError(t, err)
+	require.Equal(t, SeedRefModel{Source: SeedSourceGit, URL: "ssh://git@github.com/org/seeds.git", SubDir: "apples"}, seedRef)
+
+	t.Log("Git - scp-like URL with ref")
+	seedRef, err = ParseSeedRef("git+git@github.com:org/seeds.git//apples@main")
+	require.NoError(t, err)
+	require.Equal(t, SeedRefModel{Source: SeedSourceGit, URL: "git@github.com:org/seeds.git", SubDir: "apples", Ref: "main"}, seedRef)
+
+	t.Log("Git - refs with /")
+	for aSeed, expectedSeedRef := range map[string]SeedRefModel{
+		"git+https://host/repo.git@feature/x":           SeedRefModel{Source: SeedSourceGit, URL: "https://host/repo.git", Ref: "feature/x"},
+		"git+https://host/repo.git//apples@feature/x/y": SeedRefModel{Source: SeedSourceGit, URL: "https://host/repo.git", SubDir: "apples", Ref: "feature/x/y"},
+		"git+ssh://git@host/org/repo.git@release/1.0":   SeedRefModel{Source: SeedSourceGit, URL: "ssh://git@host/org/repo.git", Ref: "release/1.0"},
+		"git+git@host:org/repo.git//apples@feature/x":   SeedRefModel{Source: SeedSourceGit, URL: "git@host:org/repo.git", SubDir: "apples", Ref: "feature/x"},
+		"git+/path/to/repo@feature/x":                   SeedRefModel{Source: SeedSourceGit, URL: "/path/to/repo", Ref: "feature/x"},
+		"git+https://user@host/repo.git":                SeedRefModel{Source: SeedSourceGit, URL: "https://user@host/repo.git"},
+		"git+git@host:repo.git":                         SeedRefModel{Source: SeedSourceGit, URL: "git@host:repo.git"},
+	} {
+		seedRef, err = ParseSeedRef(aSeed)
+		require.NoError(t, err, aSeed)
+		require.Equal(t, expectedSeedRef, seedRef, aSeed)
+	}
+
+	t.Log("Archive - URL with subdir")
+	seedRef, err = ParseSeedRef("https://example.com/seeds-1.0.tar.gz//apples")
+	require.NoError(t, err)
+	require.Equal(t, SeedRefModel{Source: SeedSourceArchive, URL: "https://example.com/seeds-1.0.tar.gz", SubDir: "apples"}, seedRef)
+	require.True(t, seedRef.IsRemote())
+
+	t.Log("Archive - local path")
+	seedRef, err = ParseSeedRef("../dist/apples.ZIP")
+	require.NoError(t, err)
+	require.Equal(t, SeedRefModel{Source: SeedSourceArchive, URL: "../dist/apples.ZIP"}, seedRef)
+
+	t.Log("Invalid")
+	_, err = ParseSeedRef("")
+	require.Error(t, err)
+	_, err = ParseSeedRef("git+@master")
+	require.Error(t, err)
+	_, err = ParseSeedRef("git+https://host/repo.git@")
+	require.Error(t, err)
+
+	t.Log("Invalid - refs and URLs which could be read as git options")
+	for _, aSeed := range []string{
+		"git+https://host/repo.git@-x",
+		"git+https://host/repo.git@--upload-pack=touch",
+		"git+--upload-pack=touch",
+	} {
+		_, err = ParseSeedRef(aSeed)
+		require.Error(t, err, aSeed)
+	}
+
+	t.Log("Invalid - sub dir outside of the remote seed")
+	for _, aSeed := range []string{
+		"git+https://github.com/org/seeds.git//../../x@master",
+		"git+file:///path/to/repo//apples/../../x",
+		`git+https://github.com/org/seeds.git//apples\..\..\x`,
+		"https://example.com/seeds-1.0.tar.gz//../x",
+		`seeds.zip//\\server\share`,
+	} {
+		_, err = ParseSeedRef(aSeed)
+		require.Error(t, err, aSeed)
+	}
+	seedRef, err = ParseSeedRef("git+https://github.com/org/seeds.git//apples..v2/seed@master")
+	require.NoError(t, err)
+	require.Equal(t, "apples..v2/seed", seedRef.SubDir)
+}